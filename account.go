@@ -0,0 +1,154 @@
+package checkhim
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// usageDateLayout is the date format used by the usage endpoint
+const usageDateLayout = "2006-01-02"
+
+// Account describes the account owning the API key
+type Account struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Plan      string    `json:"plan,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Balance is the credit balance of the account
+type Balance struct {
+	// Credits is the number of verifications that can still be billed
+	Credits int64 `json:"credits"`
+
+	// Reserved is the number of credits held by verifications in progress
+	Reserved int64 `json:"reserved,omitempty"`
+
+	// UpdatedAt is when the balance was last computed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Available returns the credits that are not reserved
+func (b *Balance) Available() int64 {
+	return b.Credits - b.Reserved
+}
+
+// IsLow reports whether the available credits are at or below threshold
+func (b *Balance) IsLow(threshold int64) bool {
+	return b.Available() <= threshold
+}
+
+// UsageParams selects the period returned by Usage. Dates are interpreted in
+// UTC and both ends are inclusive.
+type UsageParams struct {
+	// From is the first day of the period (optional, defaults to the start
+	// of the current billing period)
+	From time.Time
+
+	// To is the last day of the period (optional, defaults to today)
+	To time.Time
+}
+
+// UsageDay is the usage of a single day
+type UsageDay struct {
+	// Date is the day, formatted as YYYY-MM-DD
+	Date string `json:"date"`
+
+	// Verifications is the number of verifications made that day
+	Verifications int64 `json:"verifications"`
+
+	// Credits is the number of credits billed that day
+	Credits int64 `json:"credits"`
+
+	// ByOutcome breaks Verifications down by result category
+	ByOutcome map[Outcome]int64 `json:"by_outcome,omitempty"`
+}
+
+// Day parses Date
+func (d UsageDay) Day() (time.Time, error) {
+	return time.Parse(usageDateLayout, d.Date)
+}
+
+// Usage is the usage history of the account
+type Usage struct {
+	// From and To bound the reported period, formatted as YYYY-MM-DD
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Days lists the usage per day, oldest first
+	Days []UsageDay `json:"days"`
+}
+
+// TotalVerifications returns the number of verifications over the period
+func (u *Usage) TotalVerifications() int64 {
+	var total int64
+	for _, day := range u.Days {
+		total += day.Verifications
+	}
+	return total
+}
+
+// TotalCredits returns the number of credits billed over the period
+func (u *Usage) TotalCredits() int64 {
+	var total int64
+	for _, day := range u.Days {
+		total += day.Credits
+	}
+	return total
+}
+
+// ByOutcome returns the number of verifications per result category over the
+// period
+func (u *Usage) ByOutcome() map[Outcome]int64 {
+	totals := make(map[Outcome]int64)
+	for _, day := range u.Days {
+		for outcome, n := range day.ByOutcome {
+			totals[outcome] += n
+		}
+	}
+	return totals
+}
+
+// Account returns the account owning the API key
+func (c *Client) Account(ctx context.Context) (*Account, error) {
+	var account Account
+	if err := c.do(ctx, http.MethodGet, "/api/account", nil, nil, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Balance returns the credit balance of the account
+func (c *Client) Balance(ctx context.Context) (*Balance, error) {
+	var balance Balance
+	if err := c.do(ctx, http.MethodGet, "/api/account/balance", nil, nil, &balance); err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// Usage returns the usage history of the account, per day and per result
+// category
+func (c *Client) Usage(ctx context.Context, params UsageParams) (*Usage, error) {
+	query := url.Values{}
+	if !params.From.IsZero() {
+		query.Set("from", params.From.UTC().Format(usageDateLayout))
+	}
+	if !params.To.IsZero() {
+		query.Set("to", params.To.UTC().Format(usageDateLayout))
+	}
+
+	path := "/api/account/usage"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var usage Usage
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -0,0 +1,182 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI is an in-memory implementation of the account endpoints. Every
+// verification costs one credit and is recorded in the usage history; once
+// the credits run out verifications fail with insufficient_credits.
+type fakeAPI struct {
+	t   *testing.T
+	now func() time.Time
+
+	mu      sync.Mutex
+	credits int64
+	usage   map[string]*UsageDay
+}
+
+func newFakeAPI(t *testing.T, credits int64) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+	api := &fakeAPI{
+		t:       t,
+		now:     func() time.Time { return time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC) },
+		credits: credits,
+		usage:   make(map[string]*UsageDay),
+	}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(f.t, "Bearer test-api-key", r.Header.Get("Authorization"))
+	w.Header().Set("Content-Type", "application/json")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/account":
+		json.NewEncoder(w).Encode(Account{
+			ID:        "acc_1",
+			Name:      "Acme",
+			Plan:      "business",
+			CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/api/account/balance":
+		json.NewEncoder(w).Encode(Balance{Credits: f.credits, UpdatedAt: f.now()})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/api/account/usage":
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		usage := Usage{From: from, To: to, Days: []UsageDay{}}
+		for day := from; day <= to; {
+			if u, ok := f.usage[day]; ok {
+				usage.Days = append(usage.Days, *u)
+			}
+			d, err := time.Parse(usageDateLayout, day)
+			require.NoError(f.t, err)
+			day = d.AddDate(0, 0, 1).Format(usageDateLayout)
+		}
+		json.NewEncoder(w).Encode(usage)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/api/verify":
+		if f.credits <= 0 {
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Insufficient credits", Code: ErrorCodeInsufficientCredits})
+			return
+		}
+		f.credits--
+
+		resp := VerifyResponse{Valid: true, Carrier: "UNITEL", Status: DeliveryStatusDeliveredToHandset}
+		day := f.now().Format(usageDateLayout)
+		u, ok := f.usage[day]
+		if !ok {
+			u = &UsageDay{Date: day, ByOutcome: make(map[Outcome]int64)}
+			f.usage[day] = u
+		}
+		u.Verifications++
+		u.Credits++
+		u.ByOutcome[resp.Outcome()]++
+		json.NewEncoder(w).Encode(resp)
+
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_Account(t *testing.T) {
+	_, server := newFakeAPI(t, 10)
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+	account, err := client.Account(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "acc_1", account.ID)
+	assert.Equal(t, "Acme", account.Name)
+	assert.Equal(t, "business", account.Plan)
+}
+
+func TestClient_Balance(t *testing.T) {
+	_, server := newFakeAPI(t, 2)
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+	ctx := context.Background()
+
+	balance, err := client.Balance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), balance.Credits)
+	assert.False(t, balance.IsLow(1))
+
+	_, err = client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+	require.NoError(t, err)
+
+	balance, err = client.Balance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), balance.Available())
+	assert.True(t, balance.IsLow(1))
+
+	_, err = client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+	require.NoError(t, err)
+	_, err = client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, ErrorCodeInsufficientCredits, apiErr.Code)
+	assert.True(t, apiErr.IsQuota())
+}
+
+func TestClient_Usage(t *testing.T) {
+	api, server := newFakeAPI(t, 10)
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+	}
+	api.now = func() time.Time { return time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC) }
+	_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+	require.NoError(t, err)
+
+	usage, err := client.Usage(ctx, UsageParams{
+		From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-03-01", usage.From)
+	assert.Equal(t, "2024-03-31", usage.To)
+	require.Len(t, usage.Days, 2)
+	assert.Equal(t, "2024-03-10", usage.Days[0].Date)
+	assert.Equal(t, int64(3), usage.Days[0].Verifications)
+
+	day, err := usage.Days[1].Day()
+	require.NoError(t, err)
+	assert.Equal(t, 11, day.Day())
+
+	assert.Equal(t, int64(4), usage.TotalVerifications())
+	assert.Equal(t, int64(4), usage.TotalCredits())
+	assert.Equal(t, map[Outcome]int64{OutcomeVerified: 4}, usage.ByOutcome())
+}
+
+func TestClient_AccountErrors(t *testing.T) {
+	server := newStaticServer(t, http.StatusUnauthorized, "application/json", `{"error":"Invalid API key","code":"unauthorized"}`)
+	defer server.Close()
+
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+	_, err := client.Balance(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.True(t, apiErr.IsAuth())
+}
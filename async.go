@@ -0,0 +1,162 @@
+package checkhim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults for polling asynchronous verifications
+const (
+	DefaultPollInitialInterval = 1 * time.Second
+	DefaultPollMaxInterval     = 30 * time.Second
+	DefaultPollMultiplier      = 2.0
+)
+
+// ErrMissingVerificationID is returned when the API accepts an asynchronous
+// verification without returning its ID
+var ErrMissingVerificationID = errors.New("checkhim: verification ID missing from response")
+
+// PollOptions configures how a Verification is polled
+type PollOptions struct {
+	// InitialInterval is the delay before the first status check (optional,
+	// defaults to DefaultPollInitialInterval)
+	InitialInterval time.Duration
+
+	// MaxInterval caps the delay between status checks (optional, defaults
+	// to DefaultPollMaxInterval)
+	MaxInterval time.Duration
+
+	// Multiplier grows the delay after every check (optional, defaults to
+	// DefaultPollMultiplier)
+	Multiplier float64
+
+	// OnStatus is called with every response whose status differs from the
+	// previous one, including the initial response (optional)
+	OnStatus func(*VerifyResponse)
+}
+
+// Verification is a handle to a verification submitted with VerifyAsync. It
+// is safe for concurrent use.
+type Verification struct {
+	// ID identifies the verification
+	ID string
+
+	client *Client
+	opts   PollOptions
+
+	mu   sync.Mutex
+	last *VerifyResponse
+}
+
+// VerifyAsync submits a verification and returns without waiting for the
+// delivery status to become final. Use Wait or Poll on the returned handle
+// to follow its progress.
+func (c *Client) VerifyAsync(ctx context.Context, req VerifyRequest, opts ...PollOptions) (*Verification, error) {
+	internalReq, header, err := c.newVerifyRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	internalReq.Async = true
+
+	var verifyResp VerifyResponse
+	if err := c.billable(ctx, func() error {
+		return c.do(ctx, http.MethodPost, "/api/verify", internalReq, header, &verifyResp)
+	}); err != nil {
+		return nil, err
+	}
+	if verifyResp.ID == "" {
+		return nil, ErrMissingVerificationID
+	}
+
+	var o PollOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = DefaultPollInitialInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = DefaultPollMaxInterval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = DefaultPollMultiplier
+	}
+
+	v := &Verification{ID: verifyResp.ID, client: c, opts: o}
+	v.record(&verifyResp)
+	return v, nil
+}
+
+// Last returns the most recent response received for the verification
+func (v *Verification) Last() *VerifyResponse {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.last
+}
+
+// Done reports whether the last known status is final
+func (v *Verification) Done() bool {
+	return v.Last().Status.IsFinal()
+}
+
+// Poll fetches the current state of the verification once
+func (v *Verification) Poll(ctx context.Context) (*VerifyResponse, error) {
+	resp, err := v.client.GetVerification(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	v.record(resp)
+	return resp, nil
+}
+
+// Wait polls the verification with exponential backoff until its status is
+// final or ctx is done. Temporary API errors are retried; other errors stop
+// the wait.
+func (v *Verification) Wait(ctx context.Context) (*VerifyResponse, error) {
+	if last := v.Last(); last.Status.IsFinal() {
+		return last, nil
+	}
+
+	interval := v.opts.InitialInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		resp, err := v.Poll(ctx)
+		if err != nil {
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || !apiErr.IsTemporary() {
+				return nil, err
+			}
+		} else if resp.Status.IsFinal() {
+			return resp, nil
+		}
+
+		interval = time.Duration(float64(interval) * v.opts.Multiplier)
+		if interval > v.opts.MaxInterval {
+			interval = v.opts.MaxInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// record stores resp as the latest response and reports status changes
+func (v *Verification) record(resp *VerifyResponse) {
+	v.mu.Lock()
+	changed := v.last == nil || v.last.Status != resp.Status
+	v.last = resp
+	v.mu.Unlock()
+
+	if changed && v.opts.OnStatus != nil {
+		v.opts.OnStatus(resp)
+	}
+}
@@ -0,0 +1,161 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAsyncServer accepts verifications asynchronously and then reports the
+// given statuses, one per status request. A status of "" answers with a
+// temporary failure.
+func newAsyncServer(t *testing.T, statuses ...DeliveryStatus) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	polls := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/verify":
+			var body struct {
+				Async bool `json:"async"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.True(t, body.Async)
+
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(VerifyResponse{ID: "ver_123", Valid: true, Status: DeliveryStatusPendingAccepted})
+
+		case r.Method == http.MethodGet && r.URL.Path == "/api/verifications/ver_123":
+			mu.Lock()
+			status := statuses[polls]
+			if polls < len(statuses)-1 {
+				polls++
+			}
+			mu.Unlock()
+
+			if status == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "try again", Code: ErrorCodeTemporaryFailure})
+				return
+			}
+			json.NewEncoder(w).Encode(VerifyResponse{ID: "ver_123", Valid: true, Carrier: "UNITEL", Status: status})
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+var fastPolling = PollOptions{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+func TestClient_VerifyAsync(t *testing.T) {
+	t.Run("waits until the status is final", func(t *testing.T) {
+		server := newAsyncServer(t,
+			DeliveryStatusPendingEnroute,
+			DeliveryStatusPendingEnroute,
+			DeliveryStatusDeliveredToHandset,
+		)
+		defer server.Close()
+
+		var seen []DeliveryStatus
+		opts := fastPolling
+		opts.OnStatus = func(resp *VerifyResponse) { seen = append(seen, resp.Status) }
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		v, err := client.VerifyAsync(context.Background(), VerifyRequest{Number: "+244921204020"}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "ver_123", v.ID)
+		assert.False(t, v.Done())
+
+		result, err := v.Wait(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, DeliveryStatusDeliveredToHandset, result.Status)
+		assert.Equal(t, OutcomeVerified, result.Outcome())
+		assert.True(t, v.Done())
+		assert.Equal(t, result, v.Last())
+		assert.Equal(t, []DeliveryStatus{
+			DeliveryStatusPendingAccepted,
+			DeliveryStatusPendingEnroute,
+			DeliveryStatusDeliveredToHandset,
+		}, seen)
+	})
+
+	t.Run("retries temporary errors while waiting", func(t *testing.T) {
+		server := newAsyncServer(t, "", DeliveryStatusUndeliverableNotDelivered)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		v, err := client.VerifyAsync(context.Background(), VerifyRequest{Number: "+244921204020"}, fastPolling)
+		require.NoError(t, err)
+
+		result, err := v.Wait(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusUndeliverableNotDelivered, result.Status)
+	})
+
+	t.Run("poll fetches the status once", func(t *testing.T) {
+		server := newAsyncServer(t, DeliveryStatusPendingEnroute, DeliveryStatusDeliveredToHandset)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		v, err := client.VerifyAsync(context.Background(), VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		result, err := v.Poll(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusPendingEnroute, result.Status)
+	})
+
+	t.Run("wait honors context cancellation", func(t *testing.T) {
+		server := newAsyncServer(t, DeliveryStatusPendingEnroute)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		v, err := client.VerifyAsync(context.Background(), VerifyRequest{Number: "+244921204020"}, fastPolling)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		_, err = v.Wait(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("already final response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			json.NewEncoder(w).Encode(VerifyResponse{ID: "ver_1", Valid: false, Status: DeliveryStatusRejectedNetwork})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		v, err := client.VerifyAsync(context.Background(), VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		result, err := v.Wait(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusRejectedNetwork, result.Status)
+	})
+
+	t.Run("missing verification ID", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(VerifyResponse{Valid: true, Status: DeliveryStatusPendingAccepted})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		_, err := client.VerifyAsync(context.Background(), VerifyRequest{Number: "+244921204020"})
+
+		assert.ErrorIs(t, err, ErrMissingVerificationID)
+	})
+}
@@ -0,0 +1,424 @@
+package checkhim
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned, wrapped in a *BudgetError, when a
+// verification would exceed a Budget limit
+var ErrBudgetExceeded = errors.New("checkhim: budget exceeded")
+
+// BudgetWindow is a rolling window over which a Budget counts verifications
+type BudgetWindow string
+
+const (
+	// BudgetHourly is the last hour, in one-minute buckets
+	BudgetHourly BudgetWindow = "hourly"
+
+	// BudgetDaily is the last 24 hours, in one-hour buckets
+	BudgetDaily BudgetWindow = "daily"
+
+	// BudgetMonthly is the last 30 days, in one-day buckets
+	BudgetMonthly BudgetWindow = "monthly"
+)
+
+// budgetWindows lists the windows in check order
+var budgetWindows = []BudgetWindow{BudgetHourly, BudgetDaily, BudgetMonthly}
+
+// bucket returns the bucket size and bucket count of the window
+func (w BudgetWindow) bucket() (time.Duration, int) {
+	switch w {
+	case BudgetHourly:
+		return time.Minute, 60
+	case BudgetDaily:
+		return time.Hour, 24
+	default:
+		return 24 * time.Hour, 30
+	}
+}
+
+// DefaultBudgetThresholds are the fractions of a limit at which
+// BudgetConfig.OnThreshold is called by default
+var DefaultBudgetThresholds = []float64{0.8, 1.0}
+
+// BudgetError reports a verification refused by a Budget. It matches
+// ErrBudgetExceeded with errors.Is.
+type BudgetError struct {
+	// Window is the window whose limit was reached
+	Window BudgetWindow
+
+	// Limit is the limit of the window
+	Limit int64
+
+	// Used is the number of verifications counted in the window
+	Used int64
+
+	// RetryAfter is an estimate of when the oldest counted verification
+	// leaves the window
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("checkhim: %s budget exceeded (%d of %d verifications used)", e.Window, e.Used, e.Limit)
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) true
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// BudgetThreshold is passed to BudgetConfig.OnThreshold when the usage of a
+// window crosses a threshold
+type BudgetThreshold struct {
+	Window    BudgetWindow
+	Threshold float64
+	Used      int64
+	Limit     int64
+}
+
+// BudgetStore persists the counters of a Budget, e.g. in Redis so that
+// several processes share a budget. Counters are identified by opaque keys
+// and may be dropped once their ttl has elapsed. Implementations must be safe
+// for concurrent use.
+type BudgetStore interface {
+	// Increment adds n (which may be negative) to the counter key
+	Increment(ctx context.Context, key string, n int64, ttl time.Duration) error
+
+	// Get returns the values of the counters keys, 0 for missing ones
+	Get(ctx context.Context, keys []string) ([]int64, error)
+}
+
+// BudgetConfig configures a Budget. A zero limit leaves the window
+// unlimited.
+type BudgetConfig struct {
+	// Hourly, Daily and Monthly are the maximum number of billable
+	// verifications in each rolling window
+	Hourly  int64
+	Daily   int64
+	Monthly int64
+
+	// Store persists the counters (optional, defaults to an in-memory store)
+	Store BudgetStore
+
+	// Prefix namespaces the counters in Store (optional, defaults to
+	// "checkhim:budget")
+	Prefix string
+
+	// Thresholds are the fractions of a limit at which OnThreshold is called
+	// (optional, defaults to DefaultBudgetThresholds)
+	Thresholds []float64
+
+	// OnThreshold is called when the usage of a window reaches a threshold
+	// (optional)
+	OnThreshold func(BudgetThreshold)
+}
+
+// Budget limits the number of billable verifications over rolling windows.
+// Set it in Config.Budget; one Budget may be shared by several clients.
+//
+// A verification is counted when it is sent. It is uncounted only when it
+// provably was not billed: it failed before reaching the API, or the API
+// refused it for authentication or quota reasons. Rejected numbers and
+// requests that time out after being sent stay counted.
+// With a shared store, concurrent processes may overshoot a limit by the
+// number of verifications they have in flight.
+type Budget struct {
+	limits      map[BudgetWindow]int64
+	store       BudgetStore
+	prefix      string
+	thresholds  []float64
+	onThreshold func(BudgetThreshold)
+	now         func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewBudget returns a Budget enforcing config
+func NewBudget(config BudgetConfig) *Budget {
+	if config.Store == nil {
+		config.Store = NewMemoryBudgetStore()
+	}
+	if config.Prefix == "" {
+		config.Prefix = "checkhim:budget"
+	}
+	if config.Thresholds == nil {
+		config.Thresholds = DefaultBudgetThresholds
+	}
+	thresholds := append([]float64(nil), config.Thresholds...)
+	sort.Float64s(thresholds)
+
+	return &Budget{
+		limits: map[BudgetWindow]int64{
+			BudgetHourly:  config.Hourly,
+			BudgetDaily:   config.Daily,
+			BudgetMonthly: config.Monthly,
+		},
+		store:       config.Store,
+		prefix:      config.Prefix,
+		thresholds:  thresholds,
+		onThreshold: config.OnThreshold,
+		now:         time.Now,
+	}
+}
+
+// Limit returns the limit of a window, 0 if it is unlimited
+func (b *Budget) Limit(window BudgetWindow) int64 {
+	return b.limits[window]
+}
+
+// Usage returns the number of verifications counted in each window
+func (b *Budget) Usage(ctx context.Context) (map[BudgetWindow]int64, error) {
+	now := b.now()
+	usage := make(map[BudgetWindow]int64, len(budgetWindows))
+	for _, window := range budgetWindows {
+		used, _, err := b.count(ctx, window, now)
+		if err != nil {
+			return nil, err
+		}
+		usage[window] = used
+	}
+	return usage, nil
+}
+
+// reserve counts one verification, refusing it if a limit is reached. The
+// returned function uncounts it.
+func (b *Budget) reserve(ctx context.Context) (func(context.Context), error) {
+	b.mu.Lock()
+
+	now := b.now()
+	used := make(map[BudgetWindow]int64, len(budgetWindows))
+	for _, window := range budgetWindows {
+		limit := b.limits[window]
+		if limit <= 0 {
+			continue
+		}
+		n, retryAfter, err := b.count(ctx, window, now)
+		if err != nil {
+			b.mu.Unlock()
+			return nil, fmt.Errorf("checkhim: failed to read budget: %w", err)
+		}
+		if n >= limit {
+			b.mu.Unlock()
+			return nil, &BudgetError{Window: window, Limit: limit, Used: n, RetryAfter: retryAfter}
+		}
+		used[window] = n
+	}
+
+	keys := make([]string, 0, len(budgetWindows))
+	for _, window := range budgetWindows {
+		size, count := window.bucket()
+		key := b.key(window, now.Truncate(size))
+		if err := b.store.Increment(ctx, key, 1, size*time.Duration(count)); err != nil {
+			b.mu.Unlock()
+			for _, k := range keys {
+				_ = b.store.Increment(ctx, k, -1, 0)
+			}
+			return nil, fmt.Errorf("checkhim: failed to update budget: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	b.mu.Unlock()
+
+	b.notify(used)
+
+	release := func(ctx context.Context) {
+		for _, key := range keys {
+			_ = b.store.Increment(ctx, key, -1, 0)
+		}
+	}
+	return release, nil
+}
+
+// notify calls OnThreshold for every threshold crossed by the verification
+// just counted, given the usage before it
+func (b *Budget) notify(before map[BudgetWindow]int64) {
+	if b.onThreshold == nil {
+		return
+	}
+	for _, window := range budgetWindows {
+		prev, ok := before[window]
+		if !ok {
+			continue
+		}
+		limit := b.limits[window]
+		for _, threshold := range b.thresholds {
+			mark := threshold * float64(limit)
+			if float64(prev) < mark && float64(prev+1) >= mark {
+				b.onThreshold(BudgetThreshold{Window: window, Threshold: threshold, Used: prev + 1, Limit: limit})
+			}
+		}
+	}
+}
+
+// count returns the verifications counted in window at now, and how long
+// until the oldest of them leaves the window
+func (b *Budget) count(ctx context.Context, window BudgetWindow, now time.Time) (int64, time.Duration, error) {
+	size, count := window.bucket()
+	current := now.Truncate(size)
+
+	keys := make([]string, count)
+	for i := range keys {
+		keys[i] = b.key(window, current.Add(-time.Duration(i)*size))
+	}
+	values, err := b.store.Get(ctx, keys)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var used int64
+	var retryAfter time.Duration
+	for i, v := range values {
+		if v <= 0 {
+			continue
+		}
+		used += v
+		// bucket i leaves the window when the window has moved count-i
+		// buckets past the current one
+		retryAfter = current.Add(time.Duration(count-i) * size).Sub(now)
+	}
+	return used, retryAfter, nil
+}
+
+func (b *Budget) key(window BudgetWindow, bucket time.Time) string {
+	return b.prefix + ":" + string(window) + ":" + strconv.FormatInt(bucket.Unix(), 10)
+}
+
+// MemoryBudgetStore is an in-process BudgetStore
+type MemoryBudgetStore struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+type memoryCounter struct {
+	value   int64
+	expires time.Time
+}
+
+// NewMemoryBudgetStore returns an empty MemoryBudgetStore
+func NewMemoryBudgetStore() *MemoryBudgetStore {
+	return &MemoryBudgetStore{now: time.Now, counters: make(map[string]*memoryCounter)}
+}
+
+// Increment implements BudgetStore. A zero ttl keeps the current expiry.
+func (s *MemoryBudgetStore) Increment(_ context.Context, key string, n int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, c := range s.counters {
+		if now.After(c.expires) {
+			delete(s.counters, k)
+		}
+	}
+
+	c, ok := s.counters[key]
+	if !ok {
+		c = &memoryCounter{expires: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.value += n
+	if ttl > 0 {
+		c.expires = now.Add(ttl)
+	}
+	return nil
+}
+
+// Get implements BudgetStore
+func (s *MemoryBudgetStore) Get(_ context.Context, keys []string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	values := make([]int64, len(keys))
+	for i, key := range keys {
+		if c, ok := s.counters[key]; ok && !now.After(c.expires) {
+			values[i] = c.value
+		}
+	}
+	return values, nil
+}
+
+// billable runs a verification request against the client's budget, if any.
+// Sandbox verifications are not billed and skip the budget.
+func (c *Client) billable(ctx context.Context, do func() error) error {
+	if c.budget == nil || c.sandbox {
+		return do()
+	}
+
+	release, err := c.budget.reserve(ctx)
+	if err != nil {
+		return err
+	}
+	if err := do(); err != nil {
+		if !billed(err) {
+			release(context.WithoutCancel(ctx))
+		}
+		return err
+	}
+	return nil
+}
+
+// unsentError marks a failure that happened before the request was sent
+type unsentError struct {
+	err error
+}
+
+func (e *unsentError) Error() string { return e.err.Error() }
+
+func (e *unsentError) Unwrap() error { return e.err }
+
+// billed reports whether a failed verification may have been billed by the
+// API. Only failures before sending, including connections that could not be
+// established, and authentication or quota refusals are known not to be.
+func billed(err error) bool {
+	var unsent *unsentError
+	if errors.As(err, &unsent) || isConnectError(err) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.IsAuth() || apiErr.IsQuota()) {
+		return false
+	}
+	return true
+}
+
+// isConnectError reports whether err happened while connecting to the API:
+// name resolution, dialing or the TLS handshake, before any request was
+// written
+func isConnectError(err error) bool {
+	var (
+		opErr        *net.OpError
+		dnsErr       *net.DNSError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		certErr      *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial",
+		errors.As(err, &dnsErr),
+		errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &certErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr),
+		errors.Is(err, ErrPinMismatch):
+		return true
+	}
+	return false
+}
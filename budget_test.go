@@ -0,0 +1,273 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedClock returns a clock starting at a fixed time that tests can advance
+func fixedClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func newBudgetClient(t *testing.T, budget *Budget, status int) (*Client, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid number", Code: ErrorCodeInvalidNumber})
+			return
+		}
+		json.NewEncoder(w).Encode(VerifyResponse{Valid: true, Status: DeliveryStatusDeliveredToHandset})
+	}))
+	t.Cleanup(server.Close)
+
+	return New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Budget: budget}), &calls
+}
+
+func TestBudget(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refuses verifications past a limit", func(t *testing.T) {
+		budget := NewBudget(BudgetConfig{Hourly: 3, Daily: 100})
+		now, _ := fixedClock()
+		budget.now = now
+		client, calls := newBudgetClient(t, budget, http.StatusOK)
+
+		for i := 0; i < 3; i++ {
+			_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+			require.NoError(t, err)
+		}
+
+		_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		assert.ErrorIs(t, err, ErrBudgetExceeded)
+
+		var budgetErr *BudgetError
+		require.ErrorAs(t, err, &budgetErr)
+		assert.Equal(t, BudgetHourly, budgetErr.Window)
+		assert.Equal(t, int64(3), budgetErr.Limit)
+		assert.Equal(t, int64(3), budgetErr.Used)
+		assert.Equal(t, time.Hour, budgetErr.RetryAfter)
+		assert.Equal(t, int32(3), atomic.LoadInt32(calls), "refused verifications do not reach the API")
+
+		usage, err := budget.Usage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[BudgetWindow]int64{BudgetHourly: 3, BudgetDaily: 3, BudgetMonthly: 3}, usage)
+	})
+
+	t.Run("windows roll", func(t *testing.T) {
+		budget := NewBudget(BudgetConfig{Hourly: 2, Daily: 3})
+		now, advance := fixedClock()
+		budget.now = now
+		client, _ := newBudgetClient(t, budget, http.StatusOK)
+
+		verify := func() error {
+			_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+			return err
+		}
+
+		require.NoError(t, verify())
+		advance(30 * time.Minute)
+		require.NoError(t, verify())
+		assert.ErrorIs(t, verify(), ErrBudgetExceeded)
+
+		// the first verification leaves the hourly window
+		advance(31 * time.Minute)
+		require.NoError(t, verify())
+
+		// but the daily limit is now reached
+		advance(time.Hour)
+		err := verify()
+		var budgetErr *BudgetError
+		require.ErrorAs(t, err, &budgetErr)
+		assert.Equal(t, BudgetDaily, budgetErr.Window)
+
+		advance(24 * time.Hour)
+		require.NoError(t, verify())
+	})
+
+	t.Run("unbilled failures are not counted", func(t *testing.T) {
+		for _, tc := range []struct {
+			status  int
+			code    string
+			counted bool
+		}{
+			{http.StatusUnauthorized, ErrorCodeUnauthorized, false},
+			{http.StatusPaymentRequired, ErrorCodeInsufficientCredits, false},
+			{http.StatusTooManyRequests, ErrorCodeRateLimitExceeded, false},
+			{http.StatusBadRequest, ErrorCodeRejectedUnknownSubscriber, true},
+			{http.StatusBadRequest, ErrorCodeInvalidNumber, true},
+			{http.StatusServiceUnavailable, ErrorCodeTemporaryFailure, true},
+		} {
+			t.Run(tc.code, func(t *testing.T) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tc.status)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "refused", Code: tc.code})
+				}))
+				defer server.Close()
+
+				budget := NewBudget(BudgetConfig{Hourly: 10})
+				client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Budget: budget})
+				_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+
+				usage, err := budget.Usage(ctx)
+				require.NoError(t, err)
+				want := int64(0)
+				if tc.counted {
+					want = 1
+				}
+				assert.Equal(t, want, usage[BudgetHourly])
+			})
+		}
+	})
+
+	t.Run("failures before sending are not counted", func(t *testing.T) {
+		budget := NewBudget(BudgetConfig{Hourly: 10})
+		client := New("test-api-key", Config{
+			BaseURL: "https://api.checkhim.tech",
+			Budget:  budget,
+			Credentials: CredentialsFunc(func(context.Context) (string, error) {
+				return "", errors.New("vault unavailable")
+			}),
+		})
+		_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		require.Error(t, err)
+
+		usage, err := budget.Usage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), usage[BudgetHourly])
+	})
+
+	t.Run("unreachable API is not counted", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		baseURL := "http://" + listener.Addr().String()
+		listener.Close()
+
+		budget := NewBudget(BudgetConfig{Hourly: 2})
+		client := New("test-api-key", Config{BaseURL: baseURL, AllowInsecureLocalhost: true, Budget: budget})
+		for i := 0; i < 3; i++ {
+			_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrBudgetExceeded)
+		}
+
+		usage, err := budget.Usage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), usage[BudgetHourly])
+	})
+
+	t.Run("TLS handshake failures are not counted", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("request sent despite an untrusted certificate")
+		}))
+		defer server.Close()
+
+		budget := NewBudget(BudgetConfig{Hourly: 10})
+		client := New("test-api-key", Config{BaseURL: server.URL, Budget: budget})
+		_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		require.Error(t, err)
+
+		usage, err := budget.Usage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), usage[BudgetHourly])
+	})
+
+	t.Run("timeouts after sending are counted", func(t *testing.T) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-done
+		}))
+		defer server.Close()
+		defer close(done)
+
+		budget := NewBudget(BudgetConfig{Hourly: 10})
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Budget: budget})
+		timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := client.VerifyWithContext(timeoutCtx, VerifyRequest{Number: "+244921204020"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		usage, err := budget.Usage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), usage[BudgetHourly])
+	})
+
+	t.Run("threshold callbacks", func(t *testing.T) {
+		var events []BudgetThreshold
+		budget := NewBudget(BudgetConfig{
+			Daily:       10,
+			OnThreshold: func(e BudgetThreshold) { events = append(events, e) },
+		})
+		client, _ := newBudgetClient(t, budget, http.StatusOK)
+
+		for i := 0; i < 10; i++ {
+			_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, []BudgetThreshold{
+			{Window: BudgetDaily, Threshold: 0.8, Used: 8, Limit: 10},
+			{Window: BudgetDaily, Threshold: 1.0, Used: 10, Limit: 10},
+		}, events)
+	})
+
+	t.Run("shared store", func(t *testing.T) {
+		store := NewMemoryBudgetStore()
+		a, _ := newBudgetClient(t, NewBudget(BudgetConfig{Monthly: 2, Store: store}), http.StatusOK)
+		b, _ := newBudgetClient(t, NewBudget(BudgetConfig{Monthly: 2, Store: store}), http.StatusOK)
+
+		_, err := a.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+		_, err = b.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		_, err = a.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+		assert.ErrorIs(t, err, ErrBudgetExceeded)
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		budget := NewBudget(BudgetConfig{})
+		client, _ := newBudgetClient(t, budget, http.StatusOK)
+
+		for i := 0; i < 5; i++ {
+			_, err := client.VerifyWithContext(ctx, VerifyRequest{Number: "+244921204020"})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(0), budget.Limit(BudgetHourly))
+	})
+}
+
+func TestMemoryBudgetStore(t *testing.T) {
+	ctx := context.Background()
+	now, advance := fixedClock()
+	store := NewMemoryBudgetStore()
+	store.now = now
+
+	require.NoError(t, store.Increment(ctx, "a", 2, time.Minute))
+	require.NoError(t, store.Increment(ctx, "a", -1, 0))
+
+	values, err := store.Get(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 0}, values)
+
+	advance(2 * time.Minute)
+	values, err = store.Get(ctx, []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0}, values)
+}
@@ -0,0 +1,273 @@
+package checkhim
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// carriersJSON is the bundled carrier database. Update data/carriers.json to
+// refresh it.
+//
+//go:embed data/carriers.json
+var carriersJSON []byte
+
+// CarrierNetwork is a mobile network code of a carrier
+type CarrierNetwork struct {
+	// MCC is the mobile country code (e.g. "631")
+	MCC string `json:"mcc"`
+
+	// MNC is the mobile network code (e.g. "02")
+	MNC string `json:"mnc"`
+}
+
+// Carrier is an entry of the carrier database
+type Carrier struct {
+	// ID is the canonical identifier of the carrier (e.g. "ao-unitel")
+	ID string `json:"id"`
+
+	// Name is the brand name of the carrier (e.g. "Unitel")
+	Name string `json:"name"`
+
+	// Operator is the legal name of the company running the network
+	Operator string `json:"operator,omitempty"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the carrier's country
+	Country string `json:"country"`
+
+	// Networks are the MCC/MNC pairs used by the carrier
+	Networks []CarrierNetwork `json:"networks,omitempty"`
+
+	// Aliases are other spellings and former brands of the carrier
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// CarrierDatabase maps carrier names and network codes to canonical carriers.
+// It is read-only once loaded and safe for concurrent use.
+type CarrierDatabase struct {
+	version   string
+	carriers  []Carrier
+	byID      map[string]int
+	byName    map[string][]int // normalized name → carriers, any country
+	byNetwork map[string]int   // mcc-mnc → carrier
+}
+
+// DefaultCarriers is the database used by the Lookup functions and
+// VerifyResponse.NormalizedCarrier, loaded from the data file bundled with
+// the SDK. Replace it with LoadCarrierDatabase before making lookups to use a
+// newer data file.
+var DefaultCarriers = mustLoadCarriers()
+
+func mustLoadCarriers() *CarrierDatabase {
+	db, err := LoadCarrierDatabase(bytes.NewReader(carriersJSON))
+	if err != nil {
+		panic(fmt.Sprintf("checkhim: invalid bundled carrier database: %v", err))
+	}
+	return db
+}
+
+// LoadCarrierDatabase reads a carrier database in the format of the bundled
+// data/carriers.json file
+func LoadCarrierDatabase(r io.Reader) (*CarrierDatabase, error) {
+	var file struct {
+		Version  string    `json:"version"`
+		Carriers []Carrier `json:"carriers"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("checkhim: failed to decode carrier database: %w", err)
+	}
+
+	db := &CarrierDatabase{
+		version:   file.Version,
+		carriers:  file.Carriers,
+		byID:      make(map[string]int, len(file.Carriers)),
+		byName:    make(map[string][]int),
+		byNetwork: make(map[string]int),
+	}
+	for i := range db.carriers {
+		c := &db.carriers[i]
+		c.ID = strings.ToLower(strings.TrimSpace(c.ID))
+		c.Country = strings.ToUpper(strings.TrimSpace(c.Country))
+		if c.ID == "" || c.Name == "" || len(c.Country) != 2 {
+			return nil, fmt.Errorf("checkhim: carrier %q needs an ID, a name and a country", c.ID)
+		}
+		if _, ok := db.byID[c.ID]; ok {
+			return nil, fmt.Errorf("checkhim: duplicate carrier ID %q", c.ID)
+		}
+		db.byID[c.ID] = i
+
+		for _, n := range c.Networks {
+			key := networkKey(n.MCC, n.MNC)
+			if other, ok := db.byNetwork[key]; ok {
+				return nil, fmt.Errorf("checkhim: network %s-%s belongs to %s and %s", n.MCC, n.MNC, db.carriers[other].ID, c.ID)
+			}
+			db.byNetwork[key] = i
+		}
+
+		names := make(map[string]bool)
+		for _, name := range append([]string{c.Name, c.ID}, c.Aliases...) {
+			key := normalizeCarrierName(name)
+			if key == "" || names[key] {
+				continue
+			}
+			names[key] = true
+			for _, other := range db.byName[key] {
+				if db.carriers[other].Country == c.Country {
+					return nil, fmt.Errorf("checkhim: carrier name %q is used by %s and %s", name, db.carriers[other].ID, c.ID)
+				}
+			}
+			db.byName[key] = append(db.byName[key], i)
+		}
+	}
+	return db, nil
+}
+
+// Version returns the version of the data file
+func (db *CarrierDatabase) Version() string {
+	return db.version
+}
+
+// Carriers returns every carrier, in data file order
+func (db *CarrierDatabase) Carriers() []Carrier {
+	carriers := make([]Carrier, len(db.carriers))
+	for i := range db.carriers {
+		carriers[i] = db.carriers[i].clone()
+	}
+	return carriers
+}
+
+// ByID returns the carrier with a canonical ID
+func (db *CarrierDatabase) ByID(id string) (Carrier, bool) {
+	i, ok := db.byID[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return Carrier{}, false
+	}
+	return db.carriers[i].clone(), true
+}
+
+// ByNetwork returns the carrier using an MCC/MNC pair
+func (db *CarrierDatabase) ByNetwork(mcc, mnc string) (Carrier, bool) {
+	i, ok := db.byNetwork[networkKey(mcc, mnc)]
+	if !ok {
+		return Carrier{}, false
+	}
+	return db.carriers[i].clone(), true
+}
+
+// ByName returns the carrier known under name in country. Case, accents,
+// spaces and punctuation are ignored. With an empty country, the name must
+// designate a single carrier: "Vodafone" is found in PT but not on its own.
+func (db *CarrierDatabase) ByName(name, country string) (Carrier, bool) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+
+	var found []int
+	for _, i := range db.byName[normalizeCarrierName(name)] {
+		if country == "" || db.carriers[i].Country == country {
+			found = append(found, i)
+		}
+	}
+	if len(found) != 1 {
+		return Carrier{}, false
+	}
+	return db.carriers[found[0]].clone(), true
+}
+
+// Normalize returns the carrier of a verification response. The current
+// network's MCC/MNC is used first, then the carrier name within the number's
+// country.
+func (db *CarrierDatabase) Normalize(r *VerifyResponse) (Carrier, bool) {
+	name, country := r.Carrier, r.Country
+	if n := r.CurrentNetwork; n != nil {
+		if n.MCC != "" && n.MNC != "" {
+			if c, ok := db.ByNetwork(n.MCC, n.MNC); ok {
+				return c, true
+			}
+		}
+		if name == "" {
+			name = n.Name
+		}
+		if country == "" {
+			country = n.Country
+		}
+	}
+	if name == "" {
+		return Carrier{}, false
+	}
+	return db.ByName(name, country)
+}
+
+// NormalizedCarrier returns the canonical carrier of the response from
+// DefaultCarriers, and false when the carrier is not in the database
+func (r *VerifyResponse) NormalizedCarrier() (Carrier, bool) {
+	return DefaultCarriers.Normalize(r)
+}
+
+// LookupCarrier returns the carrier known under name in country (optional)
+// from DefaultCarriers
+func LookupCarrier(name, country string) (Carrier, bool) {
+	return DefaultCarriers.ByName(name, country)
+}
+
+// LookupCarrierByID returns the carrier with a canonical ID from
+// DefaultCarriers
+func LookupCarrierByID(id string) (Carrier, bool) {
+	return DefaultCarriers.ByID(id)
+}
+
+// LookupCarrierByNetwork returns the carrier using an MCC/MNC pair from
+// DefaultCarriers
+func LookupCarrierByNetwork(mcc, mnc string) (Carrier, bool) {
+	return DefaultCarriers.ByNetwork(mcc, mnc)
+}
+
+// clone copies the slices so callers cannot modify the database
+func (c Carrier) clone() Carrier {
+	c.Networks = append([]CarrierNetwork(nil), c.Networks...)
+	c.Aliases = append([]string(nil), c.Aliases...)
+	return c
+}
+
+// networkKey identifies an MCC/MNC pair. Leading zeros of the MNC are
+// dropped, as the API sends both "2" and "02".
+func networkKey(mcc, mnc string) string {
+	mnc = strings.TrimLeft(strings.TrimSpace(mnc), "0")
+	if mnc == "" {
+		mnc = "0"
+	}
+	return strings.TrimSpace(mcc) + "-" + mnc
+}
+
+// accentFolds maps the accented letters found in carrier names to ASCII
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+// normalizeCarrierName folds case and accents and drops everything but
+// letters and digits, so "Telefônica Brasil" matches "TELEFONICA-BRASIL".
+// "&" is kept as "and" to tell "1&1" from "11".
+func normalizeCarrierName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if folded, ok := accentFolds[r]; ok {
+			r = folded
+		}
+		switch {
+		case r == '&':
+			b.WriteString("and")
+		case r == '+':
+			b.WriteString("plus")
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCarrierDatabase(t *testing.T) {
+	t.Run("bundled data", func(t *testing.T) {
+		assert.NotEmpty(t, DefaultCarriers.Version())
+		carriers := DefaultCarriers.Carriers()
+		require.NotEmpty(t, carriers)
+
+		countries := make(map[string]bool)
+		for _, c := range carriers {
+			countries[c.Country] = true
+			assert.NotEmpty(t, c.Networks, c.ID)
+		}
+		for _, country := range []string{"AO", "BR", "PT", "US", "DE"} {
+			assert.True(t, countries[country], country)
+		}
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		for _, tc := range []struct {
+			name, country, want string
+		}{
+			{"UNITEL", "", "ao-unitel"},
+			{"unitel s.a.", "AO", "ao-unitel"},
+			{"Telefônica Brasil", "", "br-vivo"},
+			{"TELEFONICA-BRASIL", "br", "br-vivo"},
+			{"Vodafone", "PT", "pt-vodafone"},
+			{"vodafone", "de", "de-vodafone"},
+			{"TMN", "", "pt-meo"},
+			{"1&1", "", "de-1und1"},
+			{"AT&T", "US", "us-att"},
+			{"T+", "CV", "cv-unitel-tplus"},
+		} {
+			c, ok := LookupCarrier(tc.name, tc.country)
+			require.True(t, ok, "%s/%s", tc.name, tc.country)
+			assert.Equal(t, tc.want, c.ID, "%s/%s", tc.name, tc.country)
+		}
+
+		_, ok := LookupCarrier("Vodafone", "")
+		assert.False(t, ok, "ambiguous without a country")
+		_, ok = LookupCarrier("Unitel", "BR")
+		assert.False(t, ok)
+		_, ok = LookupCarrier("", "")
+		assert.False(t, ok)
+	})
+
+	t.Run("by network", func(t *testing.T) {
+		c, ok := LookupCarrierByNetwork("631", "02")
+		require.True(t, ok)
+		assert.Equal(t, "ao-unitel", c.ID)
+
+		c, ok = LookupCarrierByNetwork("631", "2")
+		require.True(t, ok)
+		assert.Equal(t, "ao-unitel", c.ID)
+
+		c, ok = LookupCarrierByNetwork("311", "480")
+		require.True(t, ok)
+		assert.Equal(t, "us-verizon", c.ID)
+
+		_, ok = LookupCarrierByNetwork("999", "99")
+		assert.False(t, ok)
+	})
+
+	t.Run("by ID", func(t *testing.T) {
+		c, ok := LookupCarrierByID("BR-TIM")
+		require.True(t, ok)
+		assert.Equal(t, "TIM", c.Name)
+		assert.Equal(t, "BR", c.Country)
+
+		// returned carriers are copies
+		c.Aliases[0] = "changed"
+		c, _ = LookupCarrierByID("br-tim")
+		assert.NotEqual(t, "changed", c.Aliases[0])
+	})
+
+	t.Run("load", func(t *testing.T) {
+		db, err := LoadCarrierDatabase(strings.NewReader(`{"version":"test","carriers":[
+			{"id":"xx-one","name":"One","country":"xx","networks":[{"mcc":"001","mnc":"01"}]}
+		]}`))
+		require.NoError(t, err)
+		assert.Equal(t, "test", db.Version())
+		c, ok := db.ByName("one", "XX")
+		require.True(t, ok)
+		assert.Equal(t, "XX", c.Country)
+
+		for name, data := range map[string]string{
+			"malformed":         `{"carriers":[`,
+			"missing country":   `{"carriers":[{"id":"a","name":"A"}]}`,
+			"duplicate ID":      `{"carriers":[{"id":"a","name":"A","country":"XX"},{"id":"A","name":"B","country":"XX"}]}`,
+			"duplicate network": `{"carriers":[{"id":"a","name":"A","country":"XX","networks":[{"mcc":"001","mnc":"01"}]},{"id":"b","name":"B","country":"XX","networks":[{"mcc":"001","mnc":"1"}]}]}`,
+			"duplicate name":    `{"carriers":[{"id":"a","name":"Same","country":"XX"},{"id":"b","name":"B","country":"XX","aliases":["SAME"]}]}`,
+		} {
+			_, err := LoadCarrierDatabase(strings.NewReader(data))
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestVerifyResponse_NormalizedCarrier(t *testing.T) {
+	decode := func(t *testing.T, payload string) *VerifyResponse {
+		var resp VerifyResponse
+		require.NoError(t, json.Unmarshal([]byte(payload), &resp))
+		return &resp
+	}
+
+	for _, tc := range []struct {
+		name, payload, want string
+	}{
+		{"name", `{"carrier":"UNITEL","valid":true}`, "ao-unitel"},
+		{"name in country", `{"carrier":"Vodafone","valid":true,"country":"PT"}`, "pt-vodafone"},
+		{"network country", `{"carrier":"","valid":true,"current_network":{"name":"vodafone","country":"DE"}}`, "de-vodafone"},
+		{"network code wins", `{"carrier":"Vivo","valid":true,"country":"BR","current_network":{"name":"Claro","mcc":"724","mnc":"05"}}`, "br-claro"},
+		{"unknown code falls back to name", `{"carrier":"TIM Brasil","valid":true,"current_network":{"mcc":"724","mnc":"99"}}`, "br-tim"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, ok := decode(t, tc.payload).NormalizedCarrier()
+			require.True(t, ok)
+			assert.Equal(t, tc.want, c.ID)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, ok := decode(t, `{"carrier":"Acme Mobile","valid":true}`).NormalizedCarrier()
+		assert.False(t, ok)
+		_, ok = decode(t, `{"carrier":"Vodafone","valid":true}`).NormalizedCarrier()
+		assert.False(t, ok)
+		_, ok = decode(t, `{"valid":false}`).NormalizedCarrier()
+		assert.False(t, ok)
+	})
+}
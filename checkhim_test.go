@@ -16,7 +16,9 @@ func TestNew(t *testing.T) {
 	t.Run("creates client with default config", func(t *testing.T) {
 		client := New("test-api-key")
 
-		assert.Equal(t, "test-api-key", client.apiKey)
+		apiKey, err := client.credentials.APIKey(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "test-api-key", apiKey)
 		assert.Equal(t, DefaultBaseURL, client.baseURL)
 		assert.NotNil(t, client.httpClient)
 		assert.Equal(t, DefaultTimeout, client.httpClient.Timeout)
@@ -33,7 +35,9 @@ func TestNew(t *testing.T) {
 			HTTPClient: customHTTPClient,
 		})
 
-		assert.Equal(t, "test-api-key", client.apiKey)
+		apiKey, err := client.credentials.APIKey(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "test-api-key", apiKey)
 		assert.Equal(t, customBaseURL, client.baseURL)
 		assert.Equal(t, customHTTPClient, client.httpClient)
 	})
@@ -72,7 +76,7 @@ func TestClient_Verify(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := New("test-api-key", Config{BaseURL: server.URL})
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 
 		result, err := client.Verify(VerifyRequest{Number: "+1234567890"})
 
@@ -93,7 +97,7 @@ func TestClient_Verify(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := New("test-api-key", Config{BaseURL: server.URL})
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 
 		result, err := client.Verify(VerifyRequest{Number: "+invalid"})
 
@@ -114,7 +118,7 @@ func TestClient_Verify(t *testing.T) {
 		require.ErrorAs(t, err, &apiErr)
 		assert.Equal(t, 400, apiErr.StatusCode)
 		assert.Contains(t, apiErr.Message, "phone number is required")
-		assert.Equal(t, "invalid_request", apiErr.Code)
+		assert.Equal(t, ErrorCodeNumberRequired, apiErr.Code)
 	})
 
 	t.Run("API error response", func(t *testing.T) {
@@ -128,7 +132,7 @@ func TestClient_Verify(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := New("invalid-key", Config{BaseURL: server.URL})
+		client := New("invalid-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 
 		result, err := client.Verify(VerifyRequest{Number: "+1234567890"})
 
@@ -143,7 +147,7 @@ func TestClient_Verify(t *testing.T) {
 	})
 
 	t.Run("network error", func(t *testing.T) {
-		client := New("test-api-key", Config{BaseURL: "http://invalid-url-that-does-not-exist.local"})
+		client := New("test-api-key", Config{BaseURL: "https://invalid-url-that-does-not-exist.local"})
 
 		result, err := client.Verify(VerifyRequest{Number: "+1234567890"})
 
@@ -159,7 +163,7 @@ func TestClient_Verify(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := New("test-api-key", Config{BaseURL: server.URL})
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 
 		result, err := client.Verify(VerifyRequest{Number: "+1234567890"})
 
@@ -178,7 +182,7 @@ func TestClient_Verify(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := New("test-api-key", Config{BaseURL: server.URL})
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 		result, err := client.Verify(VerifyRequest{Number: "244921000111"})
 
 		require.Error(t, err)
@@ -193,6 +197,135 @@ func TestClient_Verify(t *testing.T) {
 	})
 }
 
+func TestClient_VerificationType(t *testing.T) {
+	newServer := func(t *testing.T, want VerificationType) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var internalReq struct {
+				Number string `json:"number"`
+				Type   string `json:"type"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&internalReq))
+			assert.Equal(t, string(want), internalReq.Type)
+
+			json.NewEncoder(w).Encode(VerifyResponse{Carrier: "UNITEL", Valid: true})
+		}))
+	}
+
+	t.Run("defaults to frontend", func(t *testing.T) {
+		server := newServer(t, VerificationTypeFrontend)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+	})
+
+	t.Run("client default from config", func(t *testing.T) {
+		server := newServer(t, VerificationTypeBackend)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Type: VerificationTypeBackend})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+	})
+
+	t.Run("request overrides config", func(t *testing.T) {
+		server := newServer(t, VerificationTypeFrontend)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Type: VerificationTypeBackend})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020", Type: VerificationTypeFrontend})
+		require.NoError(t, err)
+	})
+
+	t.Run("unsupported type is rejected before sending", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("request should not reach the server")
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Type: "mobile-app"})
+		result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.Error(t, err)
+		assert.Nil(t, result)
+
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, ErrorCodeInvalidRequest, apiErr.Code)
+		assert.Contains(t, apiErr.Message, "mobile-app")
+	})
+}
+
+func TestClient_RequestOptions(t *testing.T) {
+	t.Run("idempotency key, metadata and headers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "key-123", r.Header.Get("Idempotency-Key"))
+			assert.Equal(t, "tenant-a", r.Header.Get("X-Tenant"))
+			assert.Equal(t, []string{"a", "b"}, r.Header.Values("X-Trace"))
+
+			var internalReq struct {
+				Reference string            `json:"reference"`
+				Metadata  map[string]string `json:"metadata"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&internalReq))
+			assert.Equal(t, "order-42", internalReq.Reference)
+			assert.Equal(t, map[string]string{"user_id": "u-7"}, internalReq.Metadata)
+
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"carrier":   "UNITEL",
+				"valid":     true,
+				"reference": internalReq.Reference,
+				"metadata":  internalReq.Metadata,
+			})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		result, err := client.Verify(VerifyRequest{
+			Number:         "+244921204020",
+			IdempotencyKey: "key-123",
+			Reference:      "order-42",
+			Metadata:       map[string]string{"user_id": "u-7"},
+			Headers: http.Header{
+				"X-Tenant": {"tenant-a"},
+				"X-Trace":  {"a", "b"},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "order-42", result.Reference)
+		assert.Equal(t, "u-7", result.Metadata["user_id"])
+	})
+
+	t.Run("custom headers cannot override authorization", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, []string{"Bearer test-api-key"}, r.Header.Values("Authorization"))
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Empty(t, r.Header.Get("Idempotency-Key"))
+
+			json.NewEncoder(w).Encode(VerifyResponse{Valid: true})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		_, err := client.Verify(VerifyRequest{
+			Number: "+244921204020",
+			Headers: http.Header{
+				"Authorization": {"Bearer someone-else"},
+				"Content-Type":  {"text/plain"},
+			},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("idempotency keys are unique", func(t *testing.T) {
+		a, b := NewIdempotencyKey(), NewIdempotencyKey()
+		assert.Len(t, a, 32)
+		assert.NotEqual(t, a, b)
+	})
+}
+
 func TestClient_VerifyWithContext(t *testing.T) {
 	t.Run("context cancellation", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -201,7 +334,7 @@ func TestClient_VerifyWithContext(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := New("test-api-key", Config{BaseURL: server.URL})
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 
 		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
 		defer cancel()
@@ -291,7 +424,7 @@ func BenchmarkClient_Verify(b *testing.B) {
 	}))
 	defer server.Close()
 
-	client := New("test-api-key", Config{BaseURL: server.URL})
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
 	req := VerifyRequest{Number: "+1234567890"}
 
 	b.ResetTimer()
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	checkhim "github.com/checkhim/go-sdk"
+	"github.com/checkhim/go-sdk/internal/ratelimit"
+)
+
+// maxRequestBytes limits the body of a verify request
+const maxRequestBytes = 64 << 10
+
+// Values of the X-Cache response header
+const (
+	cacheHit       = "HIT"
+	cacheMiss      = "MISS"
+	cacheCoalesced = "COALESCED"
+	cacheBypass    = "BYPASS"
+)
+
+// callerConfig describes an internal caller allowed to use the gateway
+type callerConfig struct {
+	// Name identifies the caller in logs
+	Name string `json:"name"`
+
+	// Token is the bearer token the caller authenticates with
+	Token string `json:"token"`
+
+	// RatePerMinute is the sustained number of verifications allowed
+	// (0 means unlimited)
+	RatePerMinute float64 `json:"rate_per_minute"`
+
+	// Burst is the number of verifications allowed at once (defaults to
+	// RatePerMinute/60, at least 1)
+	Burst int `json:"burst"`
+}
+
+// gatewayConfig configures the gateway handler
+type gatewayConfig struct {
+	Callers []callerConfig
+
+	// CacheTTL is how long final results are reused (0 disables caching)
+	CacheTTL time.Duration
+
+	// CacheSize is the maximum number of cached results
+	CacheSize int
+}
+
+// caller is an authenticated internal caller
+type caller struct {
+	name    string
+	limiter *ratelimit.Limiter // nil when unlimited
+}
+
+// gateway serves the /api/verify contract to internal callers and forwards
+// verifications upstream with the gateway's own API key
+type gateway struct {
+	client  *checkhim.Client
+	callers map[[sha256.Size]byte]*caller
+	cache   *resultCache
+	flights *flightGroup
+	logger  *log.Logger
+}
+
+func newGateway(client *checkhim.Client, config gatewayConfig, logger *log.Logger) (*gateway, error) {
+	callers := make(map[[sha256.Size]byte]*caller, len(config.Callers))
+	for _, c := range config.Callers {
+		if c.Name == "" || c.Token == "" {
+			return nil, errors.New("gateway: every caller needs a name and a token")
+		}
+		key := sha256.Sum256([]byte(c.Token))
+		if _, ok := callers[key]; ok {
+			return nil, fmt.Errorf("gateway: caller %s reuses another caller's token", c.Name)
+		}
+
+		cl := &caller{name: c.Name}
+		if c.RatePerMinute > 0 {
+			burst := c.Burst
+			if burst <= 0 {
+				burst = int(math.Ceil(c.RatePerMinute / 60))
+			}
+			cl.limiter = ratelimit.New(c.RatePerMinute/60, burst)
+		}
+		callers[key] = cl
+	}
+
+	return &gateway{
+		client:  client,
+		callers: callers,
+		cache:   newResultCache(config.CacheTTL, config.CacheSize),
+		flights: &flightGroup{calls: make(map[string]*flight)},
+		logger:  logger,
+	}, nil
+}
+
+// ServeHTTP routes requests to the verify and health endpoints
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/verify":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed", checkhim.ErrorCodeInvalidRequest)
+			return
+		}
+		g.verify(w, r)
+	case "/healthz":
+		w.Write([]byte("ok\n"))
+	default:
+		writeError(w, http.StatusNotFound, "Not found", checkhim.ErrorCodeInvalidRequest)
+	}
+}
+
+func (g *gateway) verify(w http.ResponseWriter, r *http.Request) {
+	c := g.authenticate(r)
+	if c == nil {
+		writeError(w, http.StatusUnauthorized, "Invalid or missing token", checkhim.ErrorCodeUnauthorized)
+		return
+	}
+	if c.limiter != nil {
+		if ok, retryAfter := c.limiter.Allow(); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "Rate limit exceeded", checkhim.ErrorCodeRateLimitExceeded)
+			return
+		}
+	}
+
+	var req checkhim.VerifyRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", checkhim.ErrorCodeInvalidRequest)
+		return
+	}
+	req.Number = strings.TrimSpace(req.Number)
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		// callers share the gateway's API key, so their keys must not collide
+		req.IdempotencyKey = c.name + ":" + key
+	}
+
+	resp, source, err := g.lookup(r.Context(), req)
+	if err != nil {
+		g.logger.Printf("caller=%s number=%s error=%v", c.name, maskNumber(req.Number), err)
+		writeUpstreamError(w, err)
+		return
+	}
+
+	g.logger.Printf("caller=%s number=%s cache=%s status=%s", c.name, maskNumber(req.Number), source, resp.Status)
+	w.Header().Set("X-Cache", source)
+	writeResponse(w, resp)
+}
+
+// lookup answers req from the cache, from an identical request in flight or
+// from the API. Requests with a reference, metadata or idempotency key are
+// specific to their caller and always go upstream.
+func (g *gateway) lookup(ctx context.Context, req checkhim.VerifyRequest) (*checkhim.VerifyResponse, string, error) {
+	if req.Reference != "" || len(req.Metadata) > 0 || req.IdempotencyKey != "" {
+		resp, err := g.client.VerifyWithContext(ctx, req)
+		return resp, cacheBypass, err
+	}
+
+	key := string(req.Type) + "|" + req.Number
+	if resp, ok := g.cache.get(key); ok {
+		return resp, cacheHit, nil
+	}
+
+	resp, shared, err := g.flights.do(ctx, key, func() (*checkhim.VerifyResponse, error) {
+		// detached from the first caller's ctx so its cancellation does not
+		// fail the callers sharing the flight
+		resp, err := g.client.VerifyWithContext(context.WithoutCancel(ctx), req)
+		if err == nil && (resp.Status == "" || resp.Status.IsFinal()) {
+			g.cache.set(key, resp)
+		}
+		return resp, err
+	})
+	if shared {
+		return resp, cacheCoalesced, err
+	}
+	return resp, cacheMiss, err
+}
+
+// authenticate returns the caller presenting the request's bearer token
+func (g *gateway) authenticate(r *http.Request) *caller {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	return g.callers[sha256.Sum256([]byte(token))]
+}
+
+// writeResponse writes resp as the API sent it, unknown fields included
+func writeResponse(w http.ResponseWriter, resp *checkhim.VerifyResponse) {
+	body := []byte(resp.Raw)
+	if len(body) == 0 {
+		body, _ = json.Marshal(resp)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// writeUpstreamError relays an API error to the caller. Authentication
+// failures concern the gateway's key, not the caller's token, so they are
+// reported as a bad gateway.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	var apiErr *checkhim.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.IsAuth():
+		writeError(w, http.StatusBadGateway, "Upstream authentication failed", checkhim.ErrorCodeServiceUnavailable)
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 400:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(apiErr.StatusCode)
+		json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: apiErr.Message, Code: apiErr.Code, Details: apiErr.Details})
+	case errors.Is(err, context.Canceled):
+		// the caller went away; nobody reads the response
+	default:
+		writeError(w, http.StatusBadGateway, "Upstream unavailable", checkhim.ErrorCodeServiceUnavailable)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: message, Code: code})
+}
+
+// maskNumber keeps phone numbers out of logs except for their last digits
+func maskNumber(number string) string {
+	if len(number) <= 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
+
+// resultCache is an LRU cache of verification results with a TTL
+type resultCache struct {
+	ttl  time.Duration
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	resp    *checkhim.VerifyResponse
+	expires time.Time
+}
+
+func newResultCache(ttl time.Duration, size int) *resultCache {
+	return &resultCache{ttl: ttl, size: size, now: time.Now, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *resultCache) get(key string) (*checkhim.VerifyResponse, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.resp, true
+}
+
+func (c *resultCache) set(key string, resp *checkhim.VerifyResponse) {
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, resp: resp, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// flightGroup coalesces identical verifications in flight into one upstream
+// request
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	resp *checkhim.VerifyResponse
+	err  error
+}
+
+// do runs fn once for concurrent calls with the same key. It reports whether
+// the result was shared from another caller's call. Callers stop waiting when
+// their ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*checkhim.VerifyResponse, error)) (*checkhim.VerifyResponse, bool, error) {
+	g.mu.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.resp, true, f.err
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	f.resp, f.err = fn()
+	close(f.done)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return f.resp, false, f.err
+}
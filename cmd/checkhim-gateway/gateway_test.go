@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	checkhim "github.com/checkhim/go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream is a fake CheckHim API counting verifications per number. Numbers
+// ending in 1 are rejected with invalid_number and numbers ending in 5 stay
+// pending. When release is set, verifications block until it is closed.
+// Idempotency keys received are recorded in keys.
+type upstream struct {
+	t       *testing.T
+	calls   int32
+	release chan struct{}
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(u.t, "Bearer real-api-key", r.Header.Get("Authorization"))
+	atomic.AddInt32(&u.calls, 1)
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		u.mu.Lock()
+		u.keys = append(u.keys, key)
+		u.mu.Unlock()
+	}
+	if u.release != nil {
+		<-u.release
+	}
+
+	var req struct {
+		Number    string `json:"number"`
+		Reference string `json:"reference"`
+	}
+	require.NoError(u.t, json.NewDecoder(r.Body).Decode(&req))
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(req.Number, "1"):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: "Invalid number", Code: checkhim.ErrorCodeInvalidNumber})
+	case strings.HasSuffix(req.Number, "5"):
+		fmt.Fprintf(w, `{"valid":true,"carrier":"UNITEL","status":"PENDING_ENROUTE"}`)
+	default:
+		fmt.Fprintf(w, `{"valid":true,"carrier":"UNITEL","status":"DELIVERED_TO_HANDSET","reference":%q,"risk_score":3}`, req.Reference)
+	}
+}
+
+func newTestGateway(t *testing.T, callers ...callerConfig) (*gateway, *upstream) {
+	t.Helper()
+	up := &upstream{t: t}
+	server := httptest.NewServer(up)
+	t.Cleanup(server.Close)
+
+	if len(callers) == 0 {
+		callers = []callerConfig{{Name: "billing", Token: "billing-token"}}
+	}
+	client := checkhim.New("real-api-key", checkhim.Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+	gw, err := newGateway(client, gatewayConfig{Callers: callers, CacheTTL: time.Minute, CacheSize: 100}, log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	return gw, up
+}
+
+func call(gw http.Handler, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/verify", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, req)
+	return w
+}
+
+func TestGateway_Verify(t *testing.T) {
+	t.Run("forwards with the real key and relays the response", func(t *testing.T) {
+		gw, up := newTestGateway(t)
+
+		w := call(gw, "billing-token", `{"number":"+244921204020"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, cacheMiss, w.Header().Get("X-Cache"))
+		assert.JSONEq(t, `{"valid":true,"carrier":"UNITEL","status":"DELIVERED_TO_HANDSET","reference":"","risk_score":3}`, w.Body.String())
+		assert.Equal(t, int32(1), up.calls)
+	})
+
+	t.Run("caches final results", func(t *testing.T) {
+		gw, up := newTestGateway(t)
+
+		call(gw, "billing-token", `{"number":"+244921204020"}`)
+		w := call(gw, "billing-token", `{"number":"+244921204020"}`)
+		assert.Equal(t, cacheHit, w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(1), up.calls)
+
+		// a different type is a different verification
+		w = call(gw, "billing-token", `{"number":"+244921204020","type":"backend"}`)
+		assert.Equal(t, cacheMiss, w.Header().Get("X-Cache"))
+
+		// pending results are not cached
+		call(gw, "billing-token", `{"number":"+244921204025"}`)
+		w = call(gw, "billing-token", `{"number":"+244921204025"}`)
+		assert.Equal(t, cacheMiss, w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(4), up.calls)
+	})
+
+	t.Run("caller specific requests bypass the cache", func(t *testing.T) {
+		gw, up := newTestGateway(t)
+
+		call(gw, "billing-token", `{"number":"+244921204020"}`)
+		w := call(gw, "billing-token", `{"number":"+244921204020","reference":"order-1"}`)
+
+		assert.Equal(t, cacheBypass, w.Header().Get("X-Cache"))
+		assert.Contains(t, w.Body.String(), `"reference":"order-1"`)
+		assert.Equal(t, int32(2), up.calls)
+	})
+
+	t.Run("namespaces idempotency keys per caller", func(t *testing.T) {
+		gw, up := newTestGateway(t,
+			callerConfig{Name: "billing", Token: "billing-token"},
+			callerConfig{Name: "signup", Token: "signup-token"},
+		)
+
+		for _, token := range []string{"billing-token", "signup-token"} {
+			req := httptest.NewRequest(http.MethodPost, "/api/verify", strings.NewReader(`{"number":"+244921204020"}`))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Idempotency-Key", "key-1")
+			w := httptest.NewRecorder()
+			gw.ServeHTTP(w, req)
+			assert.Equal(t, cacheBypass, w.Header().Get("X-Cache"))
+		}
+
+		up.mu.Lock()
+		defer up.mu.Unlock()
+		assert.Equal(t, []string{"billing:key-1", "signup:key-1"}, up.keys)
+	})
+
+	t.Run("coalesces identical requests in flight", func(t *testing.T) {
+		gw, up := newTestGateway(t)
+		up.release = make(chan struct{})
+
+		const callers = 5
+		var wg sync.WaitGroup
+		sources := make(chan string, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := call(gw, "billing-token", `{"number":"+244921204020"}`)
+				assert.Equal(t, http.StatusOK, w.Code)
+				sources <- w.Header().Get("X-Cache")
+			}()
+		}
+
+		// wait until the first request reached upstream and the others queued
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&up.calls) == 1 }, time.Second, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		close(up.release)
+		wg.Wait()
+		close(sources)
+
+		counts := map[string]int{}
+		for s := range sources {
+			counts[s]++
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&up.calls))
+		assert.Equal(t, 1, counts[cacheMiss])
+		assert.Equal(t, callers-1, counts[cacheCoalesced]+counts[cacheHit])
+	})
+
+	t.Run("relays API errors", func(t *testing.T) {
+		gw, _ := newTestGateway(t)
+
+		w := call(gw, "billing-token", `{"number":"+244921204021"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var errResp checkhim.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, checkhim.ErrorCodeInvalidNumber, errResp.Code)
+	})
+
+	t.Run("rejects bad requests", func(t *testing.T) {
+		gw, up := newTestGateway(t)
+
+		assert.Equal(t, http.StatusBadRequest, call(gw, "billing-token", `{"number":`).Code)
+		assert.Equal(t, http.StatusBadRequest, call(gw, "billing-token", `{"number":""}`).Code)
+		assert.Equal(t, int32(0), up.calls)
+	})
+}
+
+func TestGateway_Auth(t *testing.T) {
+	gw, up := newTestGateway(t)
+
+	for _, token := range []string{"", "wrong-token", "real-api-key"} {
+		w := call(gw, token, `{"number":"+244921204020"}`)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, token)
+
+		var errResp checkhim.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, checkhim.ErrorCodeUnauthorized, errResp.Code)
+	}
+	assert.Equal(t, int32(0), up.calls)
+}
+
+func TestGateway_Quota(t *testing.T) {
+	gw, _ := newTestGateway(t,
+		callerConfig{Name: "batch", Token: "batch-token", RatePerMinute: 1, Burst: 2},
+		callerConfig{Name: "web", Token: "web-token"},
+	)
+
+	assert.Equal(t, http.StatusOK, call(gw, "batch-token", `{"number":"+244921204020"}`).Code)
+	assert.Equal(t, http.StatusOK, call(gw, "batch-token", `{"number":"+244921204020"}`).Code)
+
+	w := call(gw, "batch-token", `{"number":"+244921204020"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// quotas are per caller
+	assert.Equal(t, http.StatusOK, call(gw, "web-token", `{"number":"+244921204020"}`).Code)
+}
+
+func TestGateway_Routes(t *testing.T) {
+	gw, _ := newTestGateway(t)
+
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/verify", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/other", bytes.NewReader(nil)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNewGateway_InvalidCallers(t *testing.T) {
+	client := checkhim.New("real-api-key")
+
+	_, err := newGateway(client, gatewayConfig{Callers: []callerConfig{{Name: "a"}}}, log.Default())
+	assert.Error(t, err)
+
+	_, err = newGateway(client, gatewayConfig{Callers: []callerConfig{
+		{Name: "a", Token: "same"},
+		{Name: "b", Token: "same"},
+	}}, log.Default())
+	assert.ErrorContains(t, err, "reuses")
+}
+
+func TestResultCache(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newResultCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.set("a", &checkhim.VerifyResponse{ID: "a"})
+	c.set("b", &checkhim.VerifyResponse{ID: "b"})
+	_, ok := c.get("a") // a is now the most recently used
+	require.True(t, ok)
+	c.set("c", &checkhim.VerifyResponse{ID: "c"})
+
+	_, ok = c.get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	_, ok = c.get("a")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.get("c")
+	assert.False(t, ok, "expired entries are dropped")
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gateway.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"cache_ttl": "30s",
+		"callers": [{"name": "billing", "token": "t", "rate_per_minute": 60}]
+	}`), 0o600))
+
+	config, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", config.Listen)
+	assert.Equal(t, duration(30*time.Second), *config.CacheTTL)
+	assert.Equal(t, 10000, config.CacheSize)
+	assert.Equal(t, "billing", config.Callers[0].Name)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"callers": []}`), 0o600))
+	_, err = loadConfig(path)
+	assert.ErrorContains(t, err, "no callers")
+}
+
+func TestMaskNumber(t *testing.T) {
+	assert.Equal(t, "*********4020", maskNumber("+244921204020"))
+	assert.Equal(t, "****", maskNumber("123"))
+}
@@ -0,0 +1,169 @@
+// Command checkhim-gateway is an internal verification gateway. It serves the
+// CheckHim /api/verify JSON contract to internal services, authenticating
+// them with their own tokens, and forwards verifications to CheckHim with a
+// single API key held by the gateway.
+//
+// Usage:
+//
+//	CHECKHIM_API_KEY=... checkhim-gateway -config gateway.json
+//
+// The configuration file lists the callers and their quotas:
+//
+//	{
+//	  "listen": ":8080",
+//	  "cache_ttl": "10m",
+//	  "cache_size": 10000,
+//	  "callers": [
+//	    {"name": "billing", "token": "s3cr3t", "rate_per_minute": 120, "burst": 20}
+//	  ]
+//	}
+//
+// The API key is read from the CHECKHIM_API_KEY environment variable, or from
+// the file named by "api_key_file", which is reloaded when it changes.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	checkhim "github.com/checkhim/go-sdk"
+)
+
+// fileConfig is the gateway configuration file
+type fileConfig struct {
+	// Listen is the address to listen on (default ":8080")
+	Listen string `json:"listen"`
+
+	// BaseURL is the CheckHim API URL (default checkhim.DefaultBaseURL)
+	BaseURL string `json:"base_url"`
+
+	// APIKeyFile is a file holding the CheckHim API key (default: the
+	// CHECKHIM_API_KEY environment variable)
+	APIKeyFile string `json:"api_key_file"`
+
+	// Timeout bounds upstream requests (default checkhim.DefaultTimeout)
+	Timeout duration `json:"timeout"`
+
+	// CacheTTL is how long final results are reused (default 10m, "0s"
+	// disables caching)
+	CacheTTL *duration `json:"cache_ttl"`
+
+	// CacheSize is the maximum number of cached results (default 10000)
+	CacheSize int `json:"cache_size"`
+
+	Callers []callerConfig `json:"callers"`
+}
+
+// duration is a time.Duration written as a string such as "10m" in JSON
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"10m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+func loadConfig(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config fileConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if config.Listen == "" {
+		config.Listen = ":8080"
+	}
+	if config.CacheTTL == nil {
+		ttl := duration(10 * time.Minute)
+		config.CacheTTL = &ttl
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = 10000
+	}
+	if len(config.Callers) == 0 {
+		return nil, fmt.Errorf("%s: no callers configured", path)
+	}
+	return &config, nil
+}
+
+func main() {
+	configPath := flag.String("config", "gateway.json", "path to the configuration file")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "checkhim-gateway: ", log.LstdFlags)
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	var credentials checkhim.CredentialsProvider = checkhim.EnvCredentials("CHECKHIM_API_KEY")
+	if config.APIKeyFile != "" {
+		credentials = checkhim.FileCredentials(config.APIKeyFile)
+	}
+	if _, err := credentials.APIKey(context.Background()); err != nil {
+		logger.Fatal(err)
+	}
+
+	client := checkhim.New("", checkhim.Config{
+		BaseURL:     config.BaseURL,
+		Timeout:     time.Duration(config.Timeout),
+		Credentials: credentials,
+		AppName:     "checkhim-gateway",
+		AppVersion:  checkhim.Version,
+	})
+
+	gw, err := newGateway(client, gatewayConfig{
+		Callers:   config.Callers,
+		CacheTTL:  time.Duration(*config.CacheTTL),
+		CacheSize: config.CacheSize,
+	}, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	server := &http.Server{
+		Addr:              config.Listen,
+		Handler:           gw,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Printf("shutdown: %v", err)
+		}
+	}()
+
+	logger.Printf("listening on %s with %d callers", config.Listen, len(config.Callers))
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatal(err)
+	}
+	<-drained
+}
@@ -0,0 +1,218 @@
+package checkhim
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrorCategory groups error codes by their root cause
+type ErrorCategory string
+
+const (
+	// CategoryAuth covers missing, invalid or revoked credentials
+	CategoryAuth ErrorCategory = "auth"
+
+	// CategoryQuota covers rate limiting and exhausted credits
+	CategoryQuota ErrorCategory = "quota"
+
+	// CategoryInput covers invalid phone numbers
+	CategoryInput ErrorCategory = "input"
+
+	// CategoryRequest covers malformed requests and unsupported options,
+	// which say nothing about the phone number
+	CategoryRequest ErrorCategory = "request"
+
+	// CategoryNetwork covers rejections reported by the carrier network
+	CategoryNetwork ErrorCategory = "network"
+
+	// CategoryServer covers failures on the CheckHim side
+	CategoryServer ErrorCategory = "server"
+
+	// CategoryUnknown is used for codes that are not in the registry
+	CategoryUnknown ErrorCategory = "unknown"
+)
+
+// ErrorCodeInfo describes a known API error code
+type ErrorCodeInfo struct {
+	// Code is the canonical spelling of the error code
+	Code string
+
+	// Category is the root cause group of the error
+	Category ErrorCategory
+
+	// Retryable indicates whether repeating the same request may succeed
+	Retryable bool
+
+	// HTTPStatus is the HTTP status the API returns alongside the code
+	HTTPStatus int
+
+	// Description is a short technical description of the error
+	Description string
+
+	// Action is the recommended action for the caller
+	Action string
+}
+
+// errorCodes is the registry of every error code known to the SDK, keyed by
+// normalized code (see normalizeCode)
+var errorCodes = map[string]ErrorCodeInfo{}
+
+func init() {
+	for _, info := range []ErrorCodeInfo{
+		{
+			Code:        ErrorCodeUnauthorized,
+			Category:    CategoryAuth,
+			HTTPStatus:  http.StatusUnauthorized,
+			Description: "Invalid or missing API key",
+			Action:      "Check the API key configured for the client",
+		},
+		{
+			Code:        ErrorCodeRateLimitExceeded,
+			Category:    CategoryQuota,
+			Retryable:   true,
+			HTTPStatus:  http.StatusTooManyRequests,
+			Description: "Too many requests, please slow down",
+			Action:      "Retry later with exponential backoff",
+		},
+		{
+			Code:        ErrorCodeInsufficientCredits,
+			Category:    CategoryQuota,
+			HTTPStatus:  http.StatusPaymentRequired,
+			Description: "Account has insufficient credits",
+			Action:      "Top up the account balance before retrying",
+		},
+		{
+			Code:        ErrorCodeInvalidRequest,
+			Category:    CategoryRequest,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Malformed request or missing required fields",
+			Action:      "Fix the request before retrying",
+		},
+		{
+			Code:        ErrorCodeInvalidNumber,
+			Category:    CategoryInput,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Phone number format is invalid",
+			Action:      "Ask the user to correct the phone number",
+		},
+		{
+			Code:        ErrorCodeNumberRequired,
+			Category:    CategoryInput,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Phone number is missing",
+			Action:      "Ask the user to enter a phone number",
+		},
+		{
+			Code:        ErrorCodeRejectedFormat,
+			Category:    CategoryInput,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Phone number format was rejected",
+			Action:      "Ask the user to correct the phone number",
+		},
+		{
+			Code:        ErrorCodeRejectedPrefixMissing,
+			Category:    CategoryInput,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Phone number is missing the country prefix",
+			Action:      "Include the country code in the phone number",
+		},
+		{
+			Code:        ErrorCodeRejectedNetwork,
+			Category:    CategoryNetwork,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Destination network is not allowed",
+			Action:      "Do not retry; the carrier network cannot be reached",
+		},
+		{
+			Code:        ErrorCodeRejectedSubscriberAbsent,
+			Category:    CategoryNetwork,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Subscriber is absent or the handset is off",
+			Action:      "Ask the user to check that the phone is on and try again later",
+		},
+		{
+			Code:        ErrorCodeRejectedUnknownSubscriber,
+			Category:    CategoryNetwork,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Subscriber is unknown to the network",
+			Action:      "Treat the number as not in service",
+		},
+		{
+			Code:        ErrorCodeRejectedUndeliverable,
+			Category:    CategoryNetwork,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Number is not reachable on the network",
+			Action:      "Treat the number as unreachable",
+		},
+		{
+			Code:        ErrorCodeUndeliverableNotDelivered,
+			Category:    CategoryNetwork,
+			HTTPStatus:  http.StatusBadRequest,
+			Description: "Verification could not be delivered to the handset",
+			Action:      "Treat the number as unreachable",
+		},
+		{
+			Code:        ErrorCodeTemporaryFailure,
+			Category:    CategoryServer,
+			Retryable:   true,
+			HTTPStatus:  http.StatusServiceUnavailable,
+			Description: "Temporary failure while verifying",
+			Action:      "Retry later with exponential backoff",
+		},
+		{
+			Code:        ErrorCodeServiceUnavailable,
+			Category:    CategoryServer,
+			Retryable:   true,
+			HTTPStatus:  http.StatusServiceUnavailable,
+			Description: "Temporary service unavailability",
+			Action:      "Retry later with exponential backoff",
+		},
+	} {
+		errorCodes[normalizeCode(info.Code)] = info
+	}
+}
+
+// normalizeCode folds the different spellings used by the API
+// ("service_unavailable", "SERVICE_UNAVAILABLE") into a single key
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// LookupErrorCode returns the registry entry for an error code. The lookup is
+// case-insensitive.
+func LookupErrorCode(code string) (ErrorCodeInfo, bool) {
+	info, ok := errorCodes[normalizeCode(code)]
+	return info, ok
+}
+
+// ErrorCodes returns every registered error code, sorted by code
+func ErrorCodes() []ErrorCodeInfo {
+	infos := make([]ErrorCodeInfo, 0, len(errorCodes))
+	for _, info := range errorCodes {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// ErrorCodeForStatus maps an HTTP status to the error code the API uses for it.
+// It is used when an error response does not carry a code.
+func ErrorCodeForStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorCodeUnauthorized
+	case status == http.StatusPaymentRequired:
+		return ErrorCodeInsufficientCredits
+	case status == http.StatusTooManyRequests:
+		return ErrorCodeRateLimitExceeded
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return ErrorCodeInvalidRequest
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout:
+		return ErrorCodeServiceUnavailable
+	case status >= 500:
+		return ErrorCodeTemporaryFailure
+	}
+	return ""
+}
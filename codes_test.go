@@ -0,0 +1,77 @@
+package checkhim
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupErrorCode(t *testing.T) {
+	t.Run("lookup is case-insensitive", func(t *testing.T) {
+		upper, ok := LookupErrorCode("SERVICE_UNAVAILABLE")
+		require.True(t, ok)
+		lower, ok := LookupErrorCode("service_unavailable")
+		require.True(t, ok)
+
+		assert.Equal(t, upper, lower)
+		assert.Equal(t, CategoryServer, lower.Category)
+		assert.True(t, lower.Retryable)
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		_, ok := LookupErrorCode("SOMETHING_NEW")
+		assert.False(t, ok)
+	})
+
+	t.Run("every registered code is complete", func(t *testing.T) {
+		for _, info := range ErrorCodes() {
+			assert.NotEmpty(t, info.Category, info.Code)
+			assert.NotZero(t, info.HTTPStatus, info.Code)
+			assert.NotEmpty(t, info.Description, info.Code)
+			assert.NotEmpty(t, info.Action, info.Code)
+		}
+	})
+}
+
+func TestAPIError_Classification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       *APIError
+		category  ErrorCategory
+		temporary bool
+	}{
+		{"unauthorized", &APIError{StatusCode: 401, Code: ErrorCodeUnauthorized}, CategoryAuth, false},
+		{"rate limit", &APIError{StatusCode: 429, Code: ErrorCodeRateLimitExceeded}, CategoryQuota, true},
+		{"insufficient credits", &APIError{StatusCode: 402, Code: ErrorCodeInsufficientCredits}, CategoryQuota, false},
+		{"invalid number", &APIError{StatusCode: 400, Code: ErrorCodeInvalidNumber}, CategoryInput, false},
+		{"rejected format", &APIError{StatusCode: 400, Code: ErrorCodeRejectedFormat}, CategoryInput, false},
+		{"invalid request", &APIError{StatusCode: 400, Code: ErrorCodeInvalidRequest}, CategoryRequest, false},
+		{"status only 400", &APIError{StatusCode: http.StatusBadRequest}, CategoryRequest, false},
+		{"rejected network", &APIError{StatusCode: 400, Code: ErrorCodeRejectedNetwork}, CategoryNetwork, false},
+		{"lowercase service unavailable", &APIError{StatusCode: 503, Code: "service_unavailable"}, CategoryServer, true},
+		{"temporary failure", &APIError{StatusCode: 503, Code: ErrorCodeTemporaryFailure}, CategoryServer, true},
+		{"status only 429", &APIError{StatusCode: http.StatusTooManyRequests}, CategoryQuota, true},
+		{"status only 500", &APIError{StatusCode: http.StatusInternalServerError}, CategoryServer, true},
+		{"unknown code", &APIError{StatusCode: 400, Code: "brand_new"}, CategoryUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.category, tt.err.Category())
+			assert.Equal(t, tt.temporary, tt.err.IsTemporary())
+			assert.Equal(t, tt.category == CategoryAuth, tt.err.IsAuth())
+			assert.Equal(t, tt.category == CategoryQuota, tt.err.IsQuota())
+			assert.Equal(t, tt.category == CategoryInput, tt.err.IsNumberInvalid())
+			assert.Equal(t, tt.category == CategoryNetwork, tt.err.IsNetworkRelated())
+		})
+	}
+
+	t.Run("recommended action", func(t *testing.T) {
+		err := &APIError{StatusCode: 402, Code: ErrorCodeInsufficientCredits}
+		assert.Contains(t, err.RecommendedAction(), "Top up")
+
+		assert.Empty(t, (&APIError{Code: "brand_new"}).RecommendedAction())
+	})
+}
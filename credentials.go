@@ -0,0 +1,168 @@
+package checkhim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned when a CredentialsProvider has no API key
+var ErrNoCredentials = errors.New("checkhim: no API key available")
+
+// redacted replaces secrets in formatted output
+const redacted = "[REDACTED]"
+
+// CredentialsProvider supplies the API key used for a request. It is
+// consulted on every request, so keys can be rotated without recreating the
+// Client. Implementations must be safe for concurrent use.
+type CredentialsProvider interface {
+	APIKey(ctx context.Context) (string, error)
+}
+
+// CredentialsRefresher is implemented by providers that can reload their key.
+// When the API rejects a key with 401, the client calls Refresh and retries
+// the request once if the provider then returns a different key.
+type CredentialsRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// CredentialsReporter is implemented by providers that track how the API
+// answers each key, such as KeyPool. ReportResult is called after every
+// response with the key used and the resulting error, nil on success.
+type CredentialsReporter interface {
+	ReportResult(ctx context.Context, apiKey string, err *APIError)
+}
+
+// CredentialsFunc adapts a function to the CredentialsProvider interface.
+// The function is called on every request, including the retry after a 401.
+type CredentialsFunc func(ctx context.Context) (string, error)
+
+// APIKey calls f(ctx)
+func (f CredentialsFunc) APIKey(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticCredentials returns a provider that always returns apiKey
+func StaticCredentials(apiKey string) CredentialsProvider {
+	return staticCredentials{apiKey: apiKey}
+}
+
+type staticCredentials struct {
+	apiKey string
+}
+
+func (s staticCredentials) APIKey(context.Context) (string, error) {
+	if s.apiKey == "" {
+		return "", ErrNoCredentials
+	}
+	return s.apiKey, nil
+}
+
+// Format keeps the key out of fmt output, including %+v and %#v
+func (s staticCredentials) Format(f fmt.State, _ rune) {
+	fmt.Fprint(f, "checkhim.StaticCredentials("+redacted+")")
+}
+
+// EnvCredentials returns a provider that reads the API key from the named
+// environment variable on every request
+func EnvCredentials(name string) CredentialsProvider {
+	return envCredentials{name: name}
+}
+
+type envCredentials struct {
+	name string
+}
+
+func (e envCredentials) APIKey(context.Context) (string, error) {
+	key := strings.TrimSpace(os.Getenv(e.name))
+	if key == "" {
+		return "", fmt.Errorf("%w: environment variable %s is empty", ErrNoCredentials, e.name)
+	}
+	return key, nil
+}
+
+// FileCredentialsProvider reads the API key from a file, e.g. a mounted
+// Kubernetes secret. The file is re-read whenever its modification time or
+// size changes, so rotated keys are picked up without a restart.
+type FileCredentialsProvider struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// FileCredentials returns a provider that reads the API key from path.
+// Surrounding whitespace in the file is ignored.
+func FileCredentials(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{path: path}
+}
+
+// APIKey returns the key in the file, reloading it if the file changed
+func (p *FileCredentialsProvider) APIKey(context.Context) (string, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return "", fmt.Errorf("checkhim: failed to read credentials file: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.key == "" || !info.ModTime().Equal(p.modTime) || info.Size() != p.size {
+		if err := p.load(info); err != nil {
+			return "", err
+		}
+	}
+	return p.key, nil
+}
+
+// Refresh re-reads the file regardless of its modification time
+func (p *FileCredentialsProvider) Refresh(context.Context) error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("checkhim: failed to read credentials file: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load(info)
+}
+
+func (p *FileCredentialsProvider) load(info os.FileInfo) error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("checkhim: failed to read credentials file: %w", err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return fmt.Errorf("%w: credentials file %s is empty", ErrNoCredentials, p.path)
+	}
+
+	p.key = key
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	return nil
+}
+
+// Format keeps the key out of fmt output, including %+v and %#v
+func (p *FileCredentialsProvider) Format(f fmt.State, _ rune) {
+	fmt.Fprintf(f, "checkhim.FileCredentials(%s)", p.path)
+}
+
+// Format keeps the API key out of fmt output, so that printing or logging a
+// Client with %v, %+v or %#v never reveals it. It has a value receiver so
+// that Client values, and structs embedding one, are covered too.
+func (c Client) Format(f fmt.State, _ rune) {
+	fmt.Fprintf(f, "checkhim.Client{baseURL: %s, credentials: %s}", c.baseURL, redacted)
+}
+
+// String implements fmt.Stringer without revealing the API key
+func (c Client) String() string {
+	return fmt.Sprint(c)
+}
@@ -0,0 +1,227 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKeyCheckingServer accepts only the given key and counts requests
+func newKeyCheckingServer(t *testing.T, validKey string, calls *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Header.Get("Authorization") != "Bearer "+validKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid API key", Code: ErrorCodeUnauthorized})
+			return
+		}
+		json.NewEncoder(w).Encode(VerifyResponse{Carrier: "UNITEL", Valid: true})
+	}))
+}
+
+// rotatingCredentials switches to the next key when refreshed
+type rotatingCredentials struct {
+	mu        sync.Mutex
+	key       string
+	next      string
+	refreshes int32
+}
+
+func (r *rotatingCredentials) APIKey(context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.key, nil
+}
+
+func (r *rotatingCredentials) Refresh(context.Context) error {
+	atomic.AddInt32(&r.refreshes, 1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.key = r.next
+	return nil
+}
+
+func TestCredentialsProviders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("static", func(t *testing.T) {
+		key, err := StaticCredentials("sk_static").APIKey(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_static", key)
+
+		_, err = StaticCredentials("").APIKey(ctx)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	t.Run("environment variable", func(t *testing.T) {
+		t.Setenv("CHECKHIM_TEST_KEY", " sk_env \n")
+
+		provider := EnvCredentials("CHECKHIM_TEST_KEY")
+		key, err := provider.APIKey(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_env", key)
+
+		t.Setenv("CHECKHIM_TEST_KEY", "")
+		_, err = provider.APIKey(ctx)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	t.Run("file picks up rotated keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "api-key")
+		require.NoError(t, os.WriteFile(path, []byte("sk_first\n"), 0o600))
+
+		provider := FileCredentials(path)
+		key, err := provider.APIKey(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_first", key)
+
+		require.NoError(t, os.WriteFile(path, []byte("sk_second_key\n"), 0o600))
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path, later, later))
+
+		key, err = provider.APIKey(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_second_key", key)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := FileCredentials(filepath.Join(t.TempDir(), "missing")).APIKey(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("callback", func(t *testing.T) {
+		provider := CredentialsFunc(func(context.Context) (string, error) { return "sk_func", nil })
+		key, err := provider.APIKey(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "sk_func", key)
+	})
+}
+
+func TestClient_Credentials(t *testing.T) {
+	t.Run("provider is consulted per request", func(t *testing.T) {
+		var calls int32
+		server := newKeyCheckingServer(t, "sk_current", &calls)
+		defer server.Close()
+
+		var lookups int32
+		client := New("", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Credentials: CredentialsFunc(func(context.Context) (string, error) {
+				atomic.AddInt32(&lookups, 1)
+				return "sk_current", nil
+			}),
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&lookups))
+	})
+
+	t.Run("retries once with a refreshed key after 401", func(t *testing.T) {
+		var calls int32
+		server := newKeyCheckingServer(t, "sk_rotated", &calls)
+		defer server.Close()
+
+		provider := &rotatingCredentials{key: "sk_old", next: "sk_rotated"}
+
+		client := New("", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, Credentials: provider})
+		result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&provider.refreshes))
+	})
+
+	t.Run("does not retry when the key did not change", func(t *testing.T) {
+		var calls int32
+		server := newKeyCheckingServer(t, "sk_valid", &calls)
+		defer server.Close()
+
+		client := New("sk_revoked", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("retries only once", func(t *testing.T) {
+		var calls int32
+		server := newKeyCheckingServer(t, "sk_never", &calls)
+		defer server.Close()
+
+		var n int32
+		client := New("", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Credentials: CredentialsFunc(func(context.Context) (string, error) {
+				return fmt.Sprintf("sk_%d", atomic.AddInt32(&n, 1)), nil
+			}),
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.Error(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("provider errors are returned", func(t *testing.T) {
+		client := New("", Config{BaseURL: "http://127.0.0.1:1", AllowInsecureLocalhost: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+}
+
+func TestClient_RedactsAPIKey(t *testing.T) {
+	const key = "sk_live_super_secret"
+
+	path := filepath.Join(t.TempDir(), "api-key")
+	require.NoError(t, os.WriteFile(path, []byte(key), 0o600))
+
+	clients := []*Client{
+		New(key),
+		New("", Config{Credentials: StaticCredentials(key)}),
+		New("", Config{Credentials: FileCredentials(path)}),
+	}
+
+	for _, client := range clients {
+		for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+			out := fmt.Sprintf(format, client)
+			assert.NotContains(t, out, key, format)
+			assert.Contains(t, out, "checkhim.Client", format)
+		}
+		assert.NotContains(t, client.String(), key)
+
+		// values and embedding structs are formatted without the key too
+		embedding := struct {
+			Client
+			Name string
+		}{*client, "billing"}
+		for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+			assert.NotContains(t, fmt.Sprintf(format, *client), key, format)
+			assert.NotContains(t, fmt.Sprintf(format, embedding), key, format)
+		}
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		cfg := Config{Credentials: StaticCredentials(key)}
+		assert.NotContains(t, fmt.Sprintf(format, cfg), key, format)
+	}
+}
@@ -0,0 +1,267 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds
+// Config.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("checkhim: response body too large")
+
+// ErrUnexpectedContentType is returned when a successful response is not
+// JSON, e.g. an HTML page served by a proxy
+var ErrUnexpectedContentType = errors.New("checkhim: unexpected response content type")
+
+// maxErrorMessageLength bounds APIError.Message for non-JSON error bodies
+const maxErrorMessageLength = 200
+
+// DecodeError reports a response that does not match the expected shape in
+// strict decoding mode
+type DecodeError struct {
+	// UnknownFields are the fields present in the response but not modelled
+	// by the SDK
+	UnknownFields []string
+
+	// MissingFields are required fields absent from the response
+	MissingFields []string
+}
+
+// Error implements the error interface
+func (e *DecodeError) Error() string {
+	var parts []string
+	if len(e.UnknownFields) > 0 {
+		parts = append(parts, "unknown fields: "+strings.Join(e.UnknownFields, ", "))
+	}
+	if len(e.MissingFields) > 0 {
+		parts = append(parts, "missing fields: "+strings.Join(e.MissingFields, ", "))
+	}
+	return "checkhim: response does not match the expected schema (" + strings.Join(parts, "; ") + ")"
+}
+
+// requiredFielder is implemented by response types that have fields the API
+// must always send
+type requiredFielder interface {
+	requiredFields() []string
+}
+
+func (r *VerifyResponse) requiredFields() []string {
+	return []string{"valid"}
+}
+
+func (a *Account) requiredFields() []string {
+	return []string{"id"}
+}
+
+func (b *Balance) requiredFields() []string {
+	return []string{"credits"}
+}
+
+func (u *Usage) requiredFields() []string {
+	return []string{"days"}
+}
+
+func (d *UsageDay) requiredFields() []string {
+	return []string{"date"}
+}
+
+func (p *verificationPage) requiredFields() []string {
+	return []string{"data"}
+}
+
+var requiredFielderType = reflect.TypeOf((*requiredFielder)(nil)).Elem()
+
+// checkStrict compares the fields of a JSON object, and of the objects and
+// arrays nested in it, with the JSON fields of out. Nested fields are
+// reported by path, e.g. "current_network.code" or "data[0].valid".
+func checkStrict(body []byte, out interface{}) error {
+	decodeErr := &DecodeError{}
+	if err := checkStrictValue(body, reflect.TypeOf(out), "", decodeErr); err != nil {
+		return err
+	}
+
+	if len(decodeErr.UnknownFields) == 0 && len(decodeErr.MissingFields) == 0 {
+		return nil
+	}
+	sort.Strings(decodeErr.UnknownFields)
+	sort.Strings(decodeErr.MissingFields)
+	return decodeErr
+}
+
+// checkStrictValue checks a JSON value against t, adding the fields found
+// under path to decodeErr. Only objects decoded into structs are checked;
+// other values, such as timestamps sent as strings, are left to the decoder.
+func checkStrictValue(data json.RawMessage, t reflect.Type, path string, decodeErr *DecodeError) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if len(data) == 0 || data[0] != '{' {
+			return nil
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+
+		known := jsonFields(t)
+		for name, value := range fields {
+			field, ok := known[strings.ToLower(name)]
+			if !ok {
+				decodeErr.UnknownFields = append(decodeErr.UnknownFields, path+name)
+				continue
+			}
+			if err := checkStrictValue(value, field.Type, path+name+".", decodeErr); err != nil {
+				return err
+			}
+		}
+		if reflect.PtrTo(t).Implements(requiredFielderType) {
+			rf := reflect.New(t).Interface().(requiredFielder)
+			for _, name := range rf.requiredFields() {
+				if _, ok := fields[name]; !ok {
+					decodeErr.MissingFields = append(decodeErr.MissingFields, path+name)
+				}
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		if len(data) == 0 || data[0] != '[' {
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		prefix := strings.TrimSuffix(path, ".")
+		for i, item := range items {
+			if err := checkStrictValue(item, t.Elem(), fmt.Sprintf("%s[%d].", prefix, i), decodeErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the exported fields of a struct type by lower-cased
+// JSON name
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields[strings.ToLower(name)] = field
+	}
+	return fields
+}
+
+// isJSONContentType reports whether a response with the given Content-Type
+// may be decoded as JSON. A missing type and text/plain are tolerated since
+// some gateways mislabel JSON; HTML, XML and other types are not.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/plain" || strings.HasSuffix(mediaType, "+json")
+}
+
+// newAPIError converts an error response into an *APIError. JSON bodies are
+// decoded; anything else (e.g. an HTML page from a load balancer) is reduced
+// to a short, single-line message.
+func newAPIError(resp *apiResponse) *APIError {
+	if isJSONContentType(resp.contentType) {
+		var errorResp ErrorResponse
+		if err := json.Unmarshal(resp.body, &errorResp); err == nil {
+			message := errorResp.Error
+			if message == "" {
+				message = statusMessage(resp.status)
+			}
+			return &APIError{
+				StatusCode: resp.status,
+				Message:    message,
+				Code:       errorResp.Code,
+				Details:    errorResp.Details,
+			}
+		}
+	}
+
+	return &APIError{
+		StatusCode: resp.status,
+		Message:    sanitizeErrorBody(resp.body, resp.status),
+	}
+}
+
+var (
+	htmlTitlePattern  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlScriptPattern = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// sanitizeErrorBody turns a non-JSON error body into a short message: HTML is
+// reduced to its title or text, control characters and repeated whitespace
+// are removed and the result is truncated
+func sanitizeErrorBody(body []byte, status int) string {
+	text := string(body)
+	if strings.Contains(text, "<") {
+		if m := htmlTitlePattern.FindStringSubmatch(text); m != nil {
+			text = m[1]
+		} else {
+			text = htmlScriptPattern.ReplaceAllString(text, " ")
+			text = htmlTagPattern.ReplaceAllString(text, " ")
+		}
+		text = html.UnescapeString(text)
+	}
+
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+
+	if text == "" {
+		return statusMessage(status)
+	}
+	if runes := []rune(text); len(runes) > maxErrorMessageLength {
+		text = string(runes[:maxErrorMessageLength]) + "..."
+	}
+	return text
+}
+
+// statusMessage describes an HTTP status for errors without a message
+func statusMessage(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	return fmt.Sprintf("HTTP status %d", status)
+}
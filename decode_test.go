@@ -0,0 +1,215 @@
+package checkhim
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStaticServer(t *testing.T, status int, contentType, body string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestClient_MaxResponseBytes(t *testing.T) {
+	server := newStaticServer(t, http.StatusOK, "application/json", `{"valid":true,"carrier":"`+strings.Repeat("X", 2048)+`"}`)
+	defer server.Close()
+
+	t.Run("rejects oversized bodies", func(t *testing.T) {
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, MaxResponseBytes: 1024})
+		result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(DefaultMaxResponseBytes), client.maxResponseBytes)
+	})
+}
+
+func TestClient_ContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantErr     bool
+	}{
+		{"application/json", false},
+		{"application/json; charset=utf-8", false},
+		{"application/problem+json", false},
+		{"text/plain; charset=utf-8", false},
+		{"", false},
+		{"text/html; charset=utf-8", true},
+		{"application/xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			server := newStaticServer(t, http.StatusOK, tt.contentType, `{"valid":true}`)
+			defer server.Close()
+
+			client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+			_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnexpectedContentType)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_StrictDecoding(t *testing.T) {
+	t.Run("reports unknown and missing fields", func(t *testing.T) {
+		server := newStaticServer(t, http.StatusOK, "application/json", `{"carrier":"UNITEL","risk_score":3,"beta":true}`)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, StrictDecoding: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, []string{"beta", "risk_score"}, decodeErr.UnknownFields)
+		assert.Equal(t, []string{"valid"}, decodeErr.MissingFields)
+		assert.Contains(t, err.Error(), "failed to unmarshal response")
+	})
+
+	t.Run("accepts every modelled field", func(t *testing.T) {
+		server := newStaticServer(t, http.StatusOK, "application/json",
+			`{"carrier":"UNITEL","valid":true,"status":"DELIVERED_TO_HANDSET","country":"AO","line_type":"mobile","ported":false}`)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, StrictDecoding: true})
+		result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusDeliveredToHandset, result.Status)
+	})
+
+	t.Run("checks nested objects", func(t *testing.T) {
+		server := newStaticServer(t, http.StatusOK, "application/json",
+			`{"carrier":"UNITEL","valid":true,"current_network":{"name":"UNITEL","lac":"12"},"roaming":{"active":true,"network":{"mcc":"631","tac":1}}}`)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, StrictDecoding: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, []string{"current_network.lac", "roaming.network.tac"}, decodeErr.UnknownFields)
+		assert.Empty(t, decodeErr.MissingFields)
+	})
+
+	t.Run("checks account responses", func(t *testing.T) {
+		server := newStaticServer(t, http.StatusOK, "application/json", `{"reserved":5,"currency":"AOA"}`)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, StrictDecoding: true})
+		_, err := client.Balance(context.Background())
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, []string{"currency"}, decodeErr.UnknownFields)
+		assert.Equal(t, []string{"credits"}, decodeErr.MissingFields)
+	})
+
+	t.Run("checks list items", func(t *testing.T) {
+		server := newStaticServer(t, http.StatusOK, "application/json",
+			`{"data":[{"carrier":"UNITEL","valid":true},{"carrier":"UNITEL","score":1}]}`)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, StrictDecoding: true})
+		it := client.ListVerifications(ListVerificationsParams{})
+		assert.False(t, it.Next(context.Background()))
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, it.Err(), &decodeErr)
+		assert.Equal(t, []string{"data[1].score"}, decodeErr.UnknownFields)
+		assert.Equal(t, []string{"data[1].valid"}, decodeErr.MissingFields)
+	})
+
+	t.Run("lenient by default", func(t *testing.T) {
+		server := newStaticServer(t, http.StatusOK, "application/json", `{"carrier":"UNITEL","risk_score":3}`)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.NoError(t, err)
+	})
+}
+
+func TestClient_NonJSONErrorBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "HTML page is reduced to its title",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        "<html><head><title>502 Bad Gateway</title><style>body{}</style></head><body><h1>502 Bad Gateway</h1><hr>nginx</body></html>",
+			want:        "502 Bad Gateway",
+		},
+		{
+			name:        "HTML without title is reduced to text",
+			status:      http.StatusServiceUnavailable,
+			contentType: "text/html",
+			body:        "<div>\n  Service <b>down</b> &amp; out\n<script>alert(1)</script></div>",
+			want:        "Service down & out",
+		},
+		{
+			name:   "empty body uses the status text",
+			status: http.StatusGatewayTimeout,
+			want:   "Gateway Timeout",
+		},
+		{
+			name:        "control characters are removed",
+			status:      http.StatusInternalServerError,
+			contentType: "text/plain",
+			body:        "boom\x1b[31m\r\nstack\ttrace",
+			want:        "boom[31m stack trace",
+		},
+		{
+			name:        "long bodies are truncated",
+			status:      http.StatusInternalServerError,
+			contentType: "text/plain",
+			body:        strings.Repeat("a", 500),
+			want:        strings.Repeat("a", maxErrorMessageLength) + "...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStaticServer(t, tt.status, tt.contentType, tt.body)
+			defer server.Close()
+
+			client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+			_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, tt.want, apiErr.Message)
+			assert.True(t, apiErr.IsTemporary())
+		})
+	}
+}
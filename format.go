@@ -0,0 +1,259 @@
+package checkhim
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// regionsJSON is the bundled numbering metadata. Update data/regions.json to
+// add regions or change their grouping.
+//
+//go:embed data/regions.json
+var regionsJSON []byte
+
+var (
+	// ErrInvalidPhoneNumber is returned for input that is not a phone number
+	ErrInvalidPhoneNumber = errors.New("checkhim: invalid phone number")
+
+	// ErrUnknownRegion is returned for a number whose country has no
+	// numbering metadata
+	ErrUnknownRegion = errors.New("checkhim: no numbering metadata for region")
+)
+
+// NumberFormat selects how FormatNumber writes a number
+type NumberFormat int
+
+const (
+	// FormatE164 writes "+244923123456"
+	FormatE164 NumberFormat = iota
+
+	// FormatInternational writes "+244 923 123 456"
+	FormatInternational
+
+	// FormatNational writes the number as dialled within its country, e.g.
+	// "(11) 98765-4321" in Brazil or "030 1234567" in Germany
+	FormatNational
+
+	// FormatRFC3966 writes a tel URI, e.g. "tel:+244-923-123-456"
+	FormatRFC3966
+)
+
+// PhoneNumber is a number parsed by ParseNumber
+type PhoneNumber struct {
+	// Region is the ISO 3166-1 alpha-2 code of the number's country
+	Region string
+
+	// CountryCode is the calling code without "+", e.g. "244"
+	CountryCode string
+
+	// NationalNumber is the national significant number, without national
+	// prefix, e.g. "923123456"
+	NationalNumber string
+}
+
+// regionMetadata describes the numbering plan of a region
+type regionMetadata struct {
+	Region      string `json:"region"`
+	CountryCode string `json:"country_code"`
+
+	// NationalPrefix is the trunk prefix dialled before national numbers,
+	// stripped when parsing them
+	NationalPrefix string `json:"national_prefix"`
+
+	Formats []*numberFormat `json:"formats"`
+}
+
+// numberFormat groups the national significant numbers matching Pattern
+type numberFormat struct {
+	Pattern       string `json:"pattern"`
+	International string `json:"international"`
+	National      string `json:"national"`
+
+	re *regexp.Regexp
+}
+
+var (
+	regionsByCode    = make(map[string]*regionMetadata) // region code → metadata
+	regionsByCalling = make(map[string]*regionMetadata) // calling code → metadata
+)
+
+func init() {
+	var file struct {
+		Regions []*regionMetadata `json:"regions"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(regionsJSON)).Decode(&file); err != nil {
+		panic(fmt.Sprintf("checkhim: invalid bundled region metadata: %v", err))
+	}
+	for _, region := range file.Regions {
+		for _, f := range region.Formats {
+			f.re = regexp.MustCompile(`^(?:` + f.Pattern + `)$`)
+		}
+		regionsByCode[region.Region] = region
+		regionsByCalling[region.CountryCode] = region
+	}
+}
+
+// FormatRegions returns the regions with numbering metadata, sorted
+func FormatRegions() []string {
+	regions := make([]string, 0, len(regionsByCode))
+	for region := range regionsByCode {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// FormatNumber parses number with ParseNumber and writes it in format
+func FormatNumber(number, defaultRegion string, format NumberFormat) (string, error) {
+	n, err := ParseNumber(number, defaultRegion)
+	if err != nil {
+		return "", err
+	}
+	return n.Format(format), nil
+}
+
+// ParseNumber parses a phone number without network access. Numbers starting
+// with "+", "00" or "tel:" are international. Other numbers are read as
+// national numbers of defaultRegion (optional, e.g. "AO"), or as
+// international numbers written without "+", as the API accepts them.
+//
+// Numbers of regions without metadata fail with ErrUnknownRegion; see
+// FormatRegions.
+func ParseNumber(number, defaultRegion string) (*PhoneNumber, error) {
+	raw := strings.TrimSpace(number)
+	raw = strings.TrimPrefix(raw, "tel:")
+
+	international := false
+	if rest, ok := strings.CutPrefix(raw, "+"); ok {
+		raw, international = rest, true
+	}
+
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case strings.ContainsRune(" -.()/", r):
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPhoneNumber, number)
+		}
+	}
+	digits := b.String()
+	if !international {
+		if rest, ok := strings.CutPrefix(digits, "00"); ok {
+			digits, international = rest, true
+		}
+	}
+	if len(digits) < 4 || len(digits) > 15 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPhoneNumber, number)
+	}
+
+	if international {
+		return parseInternational(digits, number)
+	}
+
+	defaultRegion = strings.ToUpper(strings.TrimSpace(defaultRegion))
+	if defaultRegion == "" {
+		return parseInternational(digits, number)
+	}
+	region, ok := regionsByCode[defaultRegion]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRegion, defaultRegion)
+	}
+
+	// a national number, with or without its national prefix
+	national := digits
+	if region.NationalPrefix != "" {
+		if rest, ok := strings.CutPrefix(digits, region.NationalPrefix); ok && region.match(rest) != nil {
+			national = rest
+		}
+	}
+	if region.match(national) != nil {
+		return &PhoneNumber{Region: region.Region, CountryCode: region.CountryCode, NationalNumber: national}, nil
+	}
+
+	// an international number of the default region written without "+"
+	if rest, ok := strings.CutPrefix(digits, region.CountryCode); ok {
+		if region.match(rest) != nil {
+			return &PhoneNumber{Region: region.Region, CountryCode: region.CountryCode, NationalNumber: rest}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q is not a %s number", ErrInvalidPhoneNumber, number, region.Region)
+}
+
+// parseInternational splits digits into a calling code and a national
+// significant number
+func parseInternational(digits, number string) (*PhoneNumber, error) {
+	for size := 1; size <= 3 && size < len(digits); size++ {
+		region, ok := regionsByCalling[digits[:size]]
+		if !ok {
+			continue
+		}
+		national := digits[size:]
+		if region.match(national) == nil {
+			return nil, fmt.Errorf("%w: %q is not a %s number", ErrInvalidPhoneNumber, number, region.Region)
+		}
+		return &PhoneNumber{Region: region.Region, CountryCode: region.CountryCode, NationalNumber: national}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownRegion, number)
+}
+
+// match returns the first format whose pattern matches national
+func (r *regionMetadata) match(national string) *numberFormat {
+	for _, f := range r.Formats {
+		if f.re.MatchString(national) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Format writes the number in format
+func (n *PhoneNumber) Format(format NumberFormat) string {
+	switch format {
+	case FormatInternational:
+		return "+" + n.CountryCode + " " + n.group(false)
+	case FormatNational:
+		return n.group(true)
+	case FormatRFC3966:
+		grouped := strings.NewReplacer(" ", "-", "(", "", ")", "").Replace(n.group(false))
+		return "tel:+" + n.CountryCode + "-" + grouped
+	}
+	return n.E164()
+}
+
+// E164 returns the number in E.164 format, e.g. "+244923123456"
+func (n *PhoneNumber) E164() string {
+	return "+" + n.CountryCode + n.NationalNumber
+}
+
+// String returns the number in international format
+func (n *PhoneNumber) String() string {
+	return n.Format(FormatInternational)
+}
+
+// group applies the national or international grouping of the region's
+// format matching the number, or returns the number ungrouped
+func (n *PhoneNumber) group(national bool) string {
+	region, ok := regionsByCalling[n.CountryCode]
+	if !ok {
+		return n.NationalNumber
+	}
+	f := region.match(n.NationalNumber)
+	if f == nil {
+		return n.NationalNumber
+	}
+
+	template := f.International
+	if national {
+		template = f.National
+	}
+	match := f.re.FindStringSubmatchIndex(n.NationalNumber)
+	return string(f.re.ExpandString(nil, template, n.NationalNumber, match))
+}
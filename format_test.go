@@ -0,0 +1,119 @@
+package checkhim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatNumber(t *testing.T) {
+	for _, tc := range []struct {
+		name, number, region               string
+		e164, international, national, tel string
+	}{
+		{
+			name: "Angola", number: "+244 923 123 456",
+			e164: "+244923123456", international: "+244 923 123 456", national: "923 123 456", tel: "tel:+244-923-123-456",
+		},
+		{
+			name: "Angola without plus", number: "244921000111",
+			e164: "+244921000111", international: "+244 921 000 111", national: "921 000 111", tel: "tel:+244-921-000-111",
+		},
+		{
+			name: "Angola national", number: "923-123-456", region: "ao",
+			e164: "+244923123456", international: "+244 923 123 456", national: "923 123 456", tel: "tel:+244-923-123-456",
+		},
+		{
+			name: "Brazil mobile", number: "+55 (11) 98765-4321",
+			e164: "+5511987654321", international: "+55 11 98765-4321", national: "(11) 98765-4321", tel: "tel:+55-11-98765-4321",
+		},
+		{
+			name: "Brazil landline", number: "(21) 3456-7890", region: "BR",
+			e164: "+552134567890", international: "+55 21 3456-7890", national: "(21) 3456-7890", tel: "tel:+55-21-3456-7890",
+		},
+		{
+			name: "Brazil with national prefix", number: "011 98765 4321", region: "BR",
+			e164: "+5511987654321", international: "+55 11 98765-4321", national: "(11) 98765-4321", tel: "tel:+55-11-98765-4321",
+		},
+		{
+			name: "Portugal", number: "00351912345678",
+			e164: "+351912345678", international: "+351 912 345 678", national: "912 345 678", tel: "tel:+351-912-345-678",
+		},
+		{
+			name: "US", number: "+1 (201) 555-0123",
+			e164: "+12015550123", international: "+1 201-555-0123", national: "(201) 555-0123", tel: "tel:+1-201-555-0123",
+		},
+		{
+			name: "US with trunk prefix", number: "1-201-555-0123", region: "US",
+			e164: "+12015550123", international: "+1 201-555-0123", national: "(201) 555-0123", tel: "tel:+1-201-555-0123",
+		},
+		{
+			name: "Germany Berlin", number: "030 1234567", region: "DE",
+			e164: "+49301234567", international: "+49 30 1234567", national: "030 1234567", tel: "tel:+49-30-1234567",
+		},
+		{
+			name: "Germany mobile", number: "+49 1512 3456789",
+			e164: "+4915123456789", international: "+49 1512 3456789", national: "01512 3456789", tel: "tel:+49-1512-3456789",
+		},
+		{
+			name: "Germany Cologne", number: "0221 123456", region: "DE",
+			e164: "+49221123456", international: "+49 221 123456", national: "0221 123456", tel: "tel:+49-221-123456",
+		},
+		{
+			name: "tel URI", number: "tel:+244-923-123-456",
+			e164: "+244923123456", international: "+244 923 123 456", national: "923 123 456", tel: "tel:+244-923-123-456",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := ParseNumber(tc.number, tc.region)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.e164, n.Format(FormatE164))
+			assert.Equal(t, tc.e164, n.E164())
+			assert.Equal(t, tc.international, n.Format(FormatInternational))
+			assert.Equal(t, tc.international, n.String())
+			assert.Equal(t, tc.national, n.Format(FormatNational))
+			assert.Equal(t, tc.tel, n.Format(FormatRFC3966))
+
+			formatted, err := FormatNumber(tc.number, tc.region, FormatInternational)
+			require.NoError(t, err)
+			assert.Equal(t, tc.international, formatted)
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	n, err := ParseNumber("+244923123456", "")
+	require.NoError(t, err)
+	assert.Equal(t, PhoneNumber{Region: "AO", CountryCode: "244", NationalNumber: "923123456"}, *n)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, number := range []string{"", "abc", "+244 92a 123 456", "+244 12", "+244 9231234567", "(11) 98765-43", "+1 (055) 555-0123"} {
+			_, err := ParseNumber(number, "BR")
+			assert.ErrorIs(t, err, ErrInvalidPhoneNumber, number)
+		}
+	})
+
+	t.Run("unknown region", func(t *testing.T) {
+		_, err := ParseNumber("+81 3 1234 5678", "")
+		assert.ErrorIs(t, err, ErrUnknownRegion)
+		_, err = ParseNumber("923 123 456", "")
+		assert.ErrorIs(t, err, ErrUnknownRegion)
+		_, err = ParseNumber("923 123 456", "XX")
+		assert.ErrorIs(t, err, ErrUnknownRegion)
+	})
+
+	t.Run("regions", func(t *testing.T) {
+		regions := FormatRegions()
+		for _, region := range []string{"AO", "BR", "DE", "PT", "US"} {
+			assert.Contains(t, regions, region)
+		}
+	})
+
+	t.Run("ungrouped without metadata", func(t *testing.T) {
+		n := PhoneNumber{CountryCode: "81", NationalNumber: "312345678"}
+		assert.Equal(t, "+81 312345678", n.Format(FormatInternational))
+		assert.Equal(t, "tel:+81-312345678", n.Format(FormatRFC3966))
+	})
+}
@@ -0,0 +1,308 @@
+// Package httpverify provides net/http middleware that verifies a phone
+// number submitted in a form or JSON body before the request reaches the
+// wrapped handler:
+//
+//	client := checkhim.New(apiKey)
+//	signup := httpverify.Middleware(client, httpverify.Options{Field: "phone"})(signupHandler)
+//
+// The handler finds the verification result in the request context:
+//
+//	result, ok := httpverify.FromContext(r.Context())
+//
+// Requests with an invalid number are answered with an RFC 7807
+// application/problem+json response whose detail is localized from the
+// Accept-Language header.
+package httpverify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	checkhim "github.com/checkhim/go-sdk"
+)
+
+const (
+	// DefaultField is the field holding the phone number
+	DefaultField = "phone"
+
+	// DefaultMaxBodyBytes is the maximum request body size read to find the
+	// field
+	DefaultMaxBodyBytes = 1 << 20
+
+	// ProblemContentType is the content type of problem responses
+	ProblemContentType = "application/problem+json"
+)
+
+// Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Field is the request field the problem refers to
+	Field string `json:"field,omitempty"`
+
+	// Code is the CheckHim error code or delivery status behind the problem
+	Code string `json:"code,omitempty"`
+}
+
+// Options configures the middleware
+type Options struct {
+	// Field is the form field or JSON property holding the phone number
+	// (optional, defaults to DefaultField). Nested JSON properties are
+	// addressed with dots, e.g. "contact.phone".
+	Field string
+
+	// Optional lets requests without the field through unverified
+	Optional bool
+
+	// Type is the verification type (optional, defaults to the client's)
+	Type checkhim.VerificationType
+
+	// Accept decides whether a verification result lets the request through
+	// (optional, defaults to rejecting checkhim.OutcomeInvalid)
+	Accept func(*checkhim.VerifyResponse) bool
+
+	// Messages localizes problem details (optional, defaults to
+	// checkhim.DefaultMessages)
+	Messages *checkhim.MessageCatalog
+
+	// ProblemType is the type URI of problem responses (optional)
+	ProblemType string
+
+	// WriteProblem writes rejections (optional, defaults to WriteProblem).
+	// Use it to change the response shape or to log rejections.
+	WriteProblem func(w http.ResponseWriter, r *http.Request, p *Problem)
+
+	// MaxBodyBytes limits the request body size (optional, defaults to
+	// DefaultMaxBodyBytes)
+	MaxBodyBytes int64
+
+	// OnError is called with every failed verification, e.g. to log the
+	// upstream error code that problem responses do not reveal (optional)
+	OnError func(r *http.Request, err error)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying resp, e.g. to test handlers
+// without the middleware
+func NewContext(ctx context.Context, resp *checkhim.VerifyResponse) context.Context {
+	return context.WithValue(ctx, contextKey{}, resp)
+}
+
+// FromContext returns the verification result stored by the middleware. It
+// reports false when no number was verified, e.g. for an Optional field
+// that was not submitted.
+func FromContext(ctx context.Context) (*checkhim.VerifyResponse, bool) {
+	resp, ok := ctx.Value(contextKey{}).(*checkhim.VerifyResponse)
+	return resp, ok
+}
+
+// errFieldMissing reports a request without the phone field
+var errFieldMissing = errors.New("httpverify: field missing")
+
+// errBodyTooLarge reports a request body over MaxBodyBytes
+var errBodyTooLarge = errors.New("httpverify: request body too large")
+
+// Middleware returns middleware that verifies the phone field of every
+// request with client
+func Middleware(client *checkhim.Client, opts ...Options) func(http.Handler) http.Handler {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Field == "" {
+		o.Field = DefaultField
+	}
+	if o.Accept == nil {
+		o.Accept = func(resp *checkhim.VerifyResponse) bool {
+			return resp.Outcome() != checkhim.OutcomeInvalid
+		}
+	}
+	if o.Messages == nil {
+		o.Messages = checkhim.DefaultMessages
+	}
+	if o.WriteProblem == nil {
+		o.WriteProblem = func(w http.ResponseWriter, _ *http.Request, p *Problem) { WriteProblem(w, p) }
+	}
+	if o.MaxBodyBytes <= 0 {
+		o.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			number, err := extract(r, o.Field, o.MaxBodyBytes)
+			switch {
+			case errors.Is(err, errFieldMissing) && o.Optional:
+				next.ServeHTTP(w, r)
+				return
+			case errors.Is(err, errFieldMissing):
+				o.reject(w, r, http.StatusBadRequest, checkhim.ErrorCodeNumberRequired)
+				return
+			case errors.Is(err, errBodyTooLarge):
+				o.reject(w, r, http.StatusRequestEntityTooLarge, checkhim.ErrorCodeInvalidRequest)
+				return
+			case err != nil:
+				o.reject(w, r, http.StatusBadRequest, checkhim.ErrorCodeInvalidRequest)
+				return
+			}
+
+			resp, err := client.VerifyWithContext(r.Context(), checkhim.VerifyRequest{Number: number, Type: o.Type})
+			if err != nil {
+				o.fail(w, r, err)
+				return
+			}
+			if !o.Accept(resp) {
+				code := string(resp.Status)
+				if code == "" {
+					code = checkhim.ErrorCodeInvalidNumber
+				}
+				o.reject(w, r, http.StatusUnprocessableEntity, code)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), resp)))
+		})
+	}
+}
+
+// fail answers a request whose verification failed
+func (o *Options) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if o.OnError != nil {
+		o.OnError(r, err)
+	}
+
+	var apiErr *checkhim.APIError
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.IsNumberInvalid() || apiErr.IsNetworkRelated()):
+		// the number itself was refused, by the API or by the carrier network
+		o.reject(w, r, http.StatusUnprocessableEntity, apiErr.Code)
+	case errors.As(err, &apiErr) && !apiErr.IsTemporary():
+		// auth, quota and server errors describe the operator's account, not
+		// the end user's input; their code is only given to OnError
+		o.reject(w, r, http.StatusBadGateway, checkhim.ErrorCodeServiceUnavailable)
+	default:
+		// network errors, budget exhaustion, rate limits and temporary API
+		// failures
+		o.reject(w, r, http.StatusServiceUnavailable, checkhim.ErrorCodeServiceUnavailable)
+	}
+}
+
+// reject writes a problem for code
+func (o *Options) reject(w http.ResponseWriter, r *http.Request, status int, code string) {
+	locale := checkhim.ParseLocale(r.Header.Get("Accept-Language"))
+	o.WriteProblem(w, r, &Problem{
+		Type:     o.ProblemType,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   o.Messages.Message(locale, code),
+		Instance: r.URL.Path,
+		Field:    o.Field,
+		Code:     code,
+	})
+}
+
+// WriteProblem writes p as an application/problem+json response
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// extract returns the phone field of r, from a JSON or form body or from the
+// query string. The body is restored so the wrapped handler can read it.
+func extract(r *http.Request, field string, maxBytes int64) (string, error) {
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("httpverify: failed to read body: %w", err)
+		}
+		if int64(len(body)) > maxBytes {
+			return "", errBodyTooLarge
+		}
+	}
+
+	var value string
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var err error
+		if value, err = jsonField(body, field); err != nil {
+			return "", err
+		}
+
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", fmt.Errorf("httpverify: invalid form: %w", err)
+		}
+		value = form.Get(field)
+
+	case mediaType == "multipart/form-data":
+		clone := r.Clone(r.Context())
+		clone.Body = io.NopCloser(bytes.NewReader(body))
+		clone.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+		if err := clone.ParseMultipartForm(maxBytes); err != nil {
+			return "", fmt.Errorf("httpverify: invalid form: %w", err)
+		}
+		if values := clone.MultipartForm.Value[field]; len(values) > 0 {
+			value = values[0]
+		}
+		clone.MultipartForm.RemoveAll()
+	}
+
+	if value == "" {
+		value = r.URL.Query().Get(field)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errFieldMissing
+	}
+	return value, nil
+}
+
+// jsonField returns the string or number at a dotted path in a JSON object
+func jsonField(body []byte, path string) (string, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "", nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("httpverify: invalid JSON: %w", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", nil
+		}
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", nil
+}
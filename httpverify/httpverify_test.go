@@ -0,0 +1,291 @@
+package httpverify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	checkhim "github.com/checkhim/go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAPI answers verifications by number: numbers ending in 0 are delivered,
+// numbers ending in 1 are rejected as unknown subscribers, "+000" fails with
+// invalid_number, "+400" with a REJECTED_NETWORK error, "+401" with
+// unauthorized and "+503" with a temporary failure
+func newAPI(t *testing.T) *checkhim.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Number string `json:"number"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch {
+		case req.Number == "+000":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: "Invalid number", Code: checkhim.ErrorCodeInvalidNumber})
+		case req.Number == "+400":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: "Rejected by network", Code: checkhim.ErrorCodeRejectedNetwork})
+		case req.Number == "+401":
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: "Invalid API key", Code: checkhim.ErrorCodeUnauthorized})
+		case req.Number == "+503":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(checkhim.ErrorResponse{Error: "Try again", Code: checkhim.ErrorCodeTemporaryFailure})
+		case strings.HasSuffix(req.Number, "1"):
+			json.NewEncoder(w).Encode(checkhim.VerifyResponse{Valid: false, Status: checkhim.DeliveryStatusRejectedUnknownSubscriber})
+		default:
+			json.NewEncoder(w).Encode(checkhim.VerifyResponse{Valid: true, Carrier: "UNITEL", Status: checkhim.DeliveryStatusDeliveredToHandset})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return checkhim.New("test-api-key", checkhim.Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+}
+
+// echo responds with the verified carrier and the body it received
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	resp, ok := FromContext(r.Context())
+	body, _ := io.ReadAll(r.Body)
+	if ok {
+		w.Header().Set("X-Carrier", resp.Carrier)
+	}
+	w.Write(body)
+})
+
+func TestMiddleware(t *testing.T) {
+	client := newAPI(t)
+
+	t.Run("JSON body", func(t *testing.T) {
+		h := Middleware(client)(echo)
+		body := `{"name":"Ana","phone":"+244921204020"}`
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "UNITEL", w.Header().Get("X-Carrier"))
+		assert.Equal(t, body, w.Body.String(), "the body is still readable")
+	})
+
+	t.Run("nested JSON field", func(t *testing.T) {
+		h := Middleware(client, Options{Field: "contact.phone"})(echo)
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"contact":{"phone":"+244921204020"}}`))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, "UNITEL", w.Header().Get("X-Carrier"))
+	})
+
+	t.Run("URL-encoded form", func(t *testing.T) {
+		h := Middleware(client, Options{Field: "mobile"})(echo)
+		body := url.Values{"mobile": {"+244921204020"}}.Encode()
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, "UNITEL", w.Header().Get("X-Carrier"))
+		assert.Equal(t, body, w.Body.String())
+	})
+
+	t.Run("multipart form", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		require.NoError(t, mw.WriteField("phone", "+244921204020"))
+		require.NoError(t, mw.Close())
+
+		h := Middleware(client)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := FromContext(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, "+244921204020", r.FormValue("phone"), "the handler can still parse the form")
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/signup", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("query string", func(t *testing.T) {
+		h := Middleware(client)(echo)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/check?phone=%2B244921204020", nil))
+		assert.Equal(t, "UNITEL", w.Header().Get("X-Carrier"))
+	})
+
+	t.Run("optional field", func(t *testing.T) {
+		h := Middleware(client, Options{Optional: true})(echo)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-Carrier"))
+	})
+
+	t.Run("custom accept", func(t *testing.T) {
+		h := Middleware(client, Options{Accept: func(*checkhim.VerifyResponse) bool { return true }})(echo)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?phone=%2B244921204021", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestMiddleware_Problems(t *testing.T) {
+	client := newAPI(t)
+
+	tests := []struct {
+		name     string
+		req      func() *http.Request
+		status   int
+		code     string
+		language string
+		detail   string
+	}{
+		{
+			name:   "rejected number",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/signup?phone=%2B244921204021", nil) },
+			status: http.StatusUnprocessableEntity,
+			code:   checkhim.ErrorCodeRejectedUnknownSubscriber,
+			detail: "This phone number is not in service.",
+		},
+		{
+			name:     "localized detail",
+			req:      func() *http.Request { return httptest.NewRequest(http.MethodGet, "/signup?phone=%2B000", nil) },
+			status:   http.StatusUnprocessableEntity,
+			code:     checkhim.ErrorCodeInvalidNumber,
+			language: "pt-BR,pt;q=0.9",
+			detail:   "Este número de celular não é válido. Confira e tente novamente.",
+		},
+		{
+			name:   "rejected by the network",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/signup?phone=%2B400", nil) },
+			status: http.StatusUnprocessableEntity,
+			code:   checkhim.ErrorCodeRejectedNetwork,
+		},
+		{
+			name:   "API key refused",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/signup?phone=%2B401", nil) },
+			status: http.StatusBadGateway,
+			code:   checkhim.ErrorCodeServiceUnavailable,
+		},
+		{
+			name:   "missing field",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/signup", nil) },
+			status: http.StatusBadRequest,
+			code:   checkhim.ErrorCodeNumberRequired,
+			detail: "Please enter a phone number.",
+		},
+		{
+			name: "invalid JSON",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"phone":`))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			status: http.StatusBadRequest,
+			code:   checkhim.ErrorCodeInvalidRequest,
+			detail: "Your request could not be processed. Please try again.",
+		},
+		{
+			name:   "temporary API failure",
+			req:    func() *http.Request { return httptest.NewRequest(http.MethodGet, "/signup?phone=%2B503", nil) },
+			status: http.StatusServiceUnavailable,
+			code:   checkhim.ErrorCodeServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Middleware(client, Options{ProblemType: "https://example.com/problems/phone"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("handler called for a rejected request")
+			}))
+
+			req := tt.req()
+			if tt.language != "" {
+				req.Header.Set("Accept-Language", tt.language)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+			var p Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tt.status, p.Status)
+			assert.Equal(t, tt.code, p.Code)
+			assert.Equal(t, DefaultField, p.Field)
+			assert.Equal(t, "/signup", p.Instance)
+			assert.Equal(t, "https://example.com/problems/phone", p.Type)
+			assert.NotEmpty(t, p.Title)
+			if tt.detail != "" {
+				assert.Equal(t, tt.detail, p.Detail)
+			}
+		})
+	}
+
+	t.Run("custom writer", func(t *testing.T) {
+		var got *Problem
+		h := Middleware(client, Options{
+			WriteProblem: func(w http.ResponseWriter, r *http.Request, p *Problem) {
+				got = p
+				w.WriteHeader(http.StatusTeapot)
+			},
+		})(echo)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?phone=%2B244921204021", nil))
+
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		require.NotNil(t, got)
+		assert.Equal(t, http.StatusUnprocessableEntity, got.Status)
+	})
+
+	t.Run("upstream codes only reach OnError", func(t *testing.T) {
+		var upstream error
+		h := Middleware(client, Options{OnError: func(r *http.Request, err error) { upstream = err }})(echo)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/signup?phone=%2B401", nil))
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.NotContains(t, w.Body.String(), checkhim.ErrorCodeUnauthorized)
+		var apiErr *checkhim.APIError
+		require.ErrorAs(t, upstream, &apiErr)
+		assert.Equal(t, checkhim.ErrorCodeUnauthorized, apiErr.Code)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		h := Middleware(client, Options{MaxBodyBytes: 8})(echo)
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"phone":"+244921204020"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	resp := &checkhim.VerifyResponse{Valid: true}
+	got, ok := FromContext(NewContext(context.Background(), resp))
+	assert.True(t, ok)
+	assert.Same(t, resp, got)
+}
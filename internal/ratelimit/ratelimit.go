@@ -0,0 +1,83 @@
+// Package ratelimit implements the token bucket shared by the gateway and the
+// client pool.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket refilled at a constant rate. It is safe for
+// concurrent use.
+type Limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// New returns a Limiter allowing rate events per second with bursts of up to
+// burst events. The bucket starts full. A burst below 1 is raised to 1.
+func New(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), now: time.Now}
+}
+
+// Allow takes a token if one is available. Otherwise it reports how long
+// until one will be.
+func (l *Limiter) Allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	return false, l.wait()
+}
+
+// Wait takes a token, blocking until one is available or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		ok, delay := l.Allow()
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds the tokens earned since the last call
+func (l *Limiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// wait returns the time until a token is available
+func (l *Limiter) wait() time.Duration {
+	if l.rate <= 0 {
+		// never refilled; callers waiting on it only stop with their ctx
+		return time.Hour
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
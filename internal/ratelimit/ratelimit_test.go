@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := New(2, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow()
+		assert.True(t, ok, "burst %d", i)
+	}
+
+	ok, delay := l.Allow()
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, delay)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow()
+	assert.True(t, ok)
+
+	// the bucket never holds more than burst tokens
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ = l.Allow()
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow()
+	assert.False(t, ok)
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	t.Run("waits for a token", func(t *testing.T) {
+		l := New(1000, 1)
+		assert.NoError(t, l.Wait(context.Background()))
+		assert.NoError(t, l.Wait(context.Background()))
+	})
+
+	t.Run("honors context cancellation", func(t *testing.T) {
+		l := New(0, 1)
+		assert.NoError(t, l.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,268 @@
+package checkhim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for benching keys in a KeyPool
+const (
+	DefaultRateLimitBench = 1 * time.Minute
+	DefaultCreditsBench   = 1 * time.Hour
+	DefaultAuthBench      = 1 * time.Hour
+)
+
+// ErrAllKeysBenched is returned by KeyPool when every key is benched. It
+// wraps ErrNoCredentials.
+var ErrAllKeysBenched = fmt.Errorf("%w: every API key is benched", ErrNoCredentials)
+
+// errKeyPoolWithCredentials reports conflicting credential options
+var errKeyPoolWithCredentials = errors.New("checkhim: Config.APIKeys cannot be combined with Config.Credentials")
+
+// KeyStrategy selects the next key of a KeyPool
+type KeyStrategy int
+
+const (
+	// KeyStrategyRoundRobin uses the available keys in turn
+	KeyStrategyRoundRobin KeyStrategy = iota
+
+	// KeyStrategyLeastRecentlyRateLimited prefers the available key that was
+	// rate limited the longest time ago, then the least used one
+	KeyStrategyLeastRecentlyRateLimited
+)
+
+// KeyPoolConfig configures a KeyPool
+type KeyPoolConfig struct {
+	// Strategy selects the next key (optional, defaults to
+	// KeyStrategyRoundRobin)
+	Strategy KeyStrategy
+
+	// RateLimitBench is how long a key is left out after a
+	// rate_limit_exceeded error (optional, defaults to DefaultRateLimitBench)
+	RateLimitBench time.Duration
+
+	// CreditsBench is how long a key is left out after an
+	// insufficient_credits error (optional, defaults to DefaultCreditsBench)
+	CreditsBench time.Duration
+
+	// AuthBench is how long a key is left out after the API refuses it, e.g.
+	// because it was revoked (optional, defaults to DefaultAuthBench)
+	AuthBench time.Duration
+}
+
+// KeyHealth reports the state of a key in a KeyPool
+type KeyHealth struct {
+	// Key is the masked API key, e.g. "****7f3a"
+	Key string
+
+	// Available reports whether the key is in rotation
+	Available bool
+
+	// BenchedUntil is when a benched key returns to rotation
+	BenchedUntil time.Time
+
+	// BenchReason is the error code that benched the key
+	BenchReason string
+
+	// Requests, Failures and RateLimited count the responses received with
+	// the key
+	Requests    int64
+	Failures    int64
+	RateLimited int64
+
+	// LastRateLimited is when the key was last rate limited
+	LastRateLimited time.Time
+}
+
+// KeyPool is a CredentialsProvider spreading requests over several API keys.
+// A key refused with rate_limit_exceeded or insufficient_credits is benched
+// for a while, and the client retries the request once with another key. So
+// is a key the API rejects as unauthorized. It
+// is safe for concurrent use.
+type KeyPool struct {
+	config KeyPoolConfig
+	now    func() time.Time
+
+	mu   sync.Mutex
+	keys []*poolKey
+	next int
+}
+
+type poolKey struct {
+	key             string
+	benchedUntil    time.Time
+	benchReason     string
+	requests        int64
+	failures        int64
+	rateLimited     int64
+	lastRateLimited time.Time
+}
+
+// NewKeyPool returns a KeyPool over keys. Empty and duplicate keys are
+// ignored.
+func NewKeyPool(keys []string, configs ...KeyPoolConfig) *KeyPool {
+	var config KeyPoolConfig
+	if len(configs) > 0 {
+		config = configs[0]
+	}
+	if config.RateLimitBench <= 0 {
+		config.RateLimitBench = DefaultRateLimitBench
+	}
+	if config.CreditsBench <= 0 {
+		config.CreditsBench = DefaultCreditsBench
+	}
+	if config.AuthBench <= 0 {
+		config.AuthBench = DefaultAuthBench
+	}
+
+	p := &KeyPool{config: config, now: time.Now}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		p.keys = append(p.keys, &poolKey{key: key})
+	}
+	return p
+}
+
+// APIKey returns the next available key
+func (p *KeyPool) APIKey(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return "", ErrNoCredentials
+	}
+
+	now := p.now()
+	var chosen *poolKey
+	switch p.config.Strategy {
+	case KeyStrategyLeastRecentlyRateLimited:
+		for _, k := range p.keys {
+			if !k.available(now) {
+				continue
+			}
+			if chosen == nil || k.lastRateLimited.Before(chosen.lastRateLimited) ||
+				(k.lastRateLimited.Equal(chosen.lastRateLimited) && k.requests < chosen.requests) {
+				chosen = k
+			}
+		}
+	default:
+		for i := 0; i < len(p.keys); i++ {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if k.available(now) {
+				chosen = k
+				p.next = (p.next + i + 1) % len(p.keys)
+				break
+			}
+		}
+	}
+
+	if chosen == nil {
+		return "", ErrAllKeysBenched
+	}
+	return chosen.key, nil
+}
+
+// ReportResult implements CredentialsReporter, benching keys refused for
+// quota or authentication reasons
+func (p *KeyPool) ReportResult(_ context.Context, apiKey string, err *APIError) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var k *poolKey
+	for _, candidate := range p.keys {
+		if candidate.key == apiKey {
+			k = candidate
+			break
+		}
+	}
+	if k == nil {
+		return
+	}
+
+	k.requests++
+	if err == nil {
+		return
+	}
+	k.failures++
+
+	now := p.now()
+	info, _ := err.Info()
+	switch {
+	case info.Code == ErrorCodeRateLimitExceeded:
+		k.rateLimited++
+		k.lastRateLimited = now
+		k.bench(now.Add(p.config.RateLimitBench), info.Code)
+	case info.Code == ErrorCodeInsufficientCredits:
+		k.bench(now.Add(p.config.CreditsBench), info.Code)
+	case info.Category == CategoryAuth:
+		k.bench(now.Add(p.config.AuthBench), info.Code)
+	}
+}
+
+// Health reports the state of every key, in the order they were given
+func (p *KeyPool) Health() []KeyHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	health := make([]KeyHealth, len(p.keys))
+	for i, k := range p.keys {
+		health[i] = KeyHealth{
+			Key:             maskKey(k.key),
+			Available:       k.available(now),
+			Requests:        k.requests,
+			Failures:        k.failures,
+			RateLimited:     k.rateLimited,
+			LastRateLimited: k.lastRateLimited,
+		}
+		if !health[i].Available {
+			health[i].BenchedUntil = k.benchedUntil
+			health[i].BenchReason = k.benchReason
+		}
+	}
+	return health
+}
+
+// Format keeps the keys out of fmt output
+func (p *KeyPool) Format(f fmt.State, _ rune) {
+	fmt.Fprintf(f, "checkhim.KeyPool(%d keys %s)", len(p.keys), redacted)
+}
+
+func (k *poolKey) available(now time.Time) bool {
+	return !now.Before(k.benchedUntil)
+}
+
+// bench takes the key out of rotation until the later of its current and
+// the new deadline
+func (k *poolKey) bench(until time.Time, reason string) {
+	if until.After(k.benchedUntil) {
+		k.benchedUntil = until
+		k.benchReason = reason
+	}
+}
+
+// maskKey keeps the last four characters of a key
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
+
+// KeyHealth reports the health of the client's keys when it was configured
+// with several (see Config.APIKeys), and nil otherwise
+func (c *Client) KeyHealth() []KeyHealth {
+	if pool, ok := c.credentials.(*KeyPool); ok {
+		return pool.Health()
+	}
+	return nil
+}
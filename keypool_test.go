@@ -0,0 +1,209 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKeyServer answers each key with the status configured for it (200 by
+// default) and records the keys it saw
+func newKeyServer(t *testing.T, statuses map[string]int) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		mu.Lock()
+		seen = append(seen, key)
+		status := statuses[key]
+		mu.Unlock()
+
+		switch status {
+		case http.StatusTooManyRequests:
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Too many requests", Code: ErrorCodeRateLimitExceeded})
+		case http.StatusUnauthorized:
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid API key", Code: ErrorCodeUnauthorized})
+		case http.StatusPaymentRequired:
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Insufficient credits", Code: ErrorCodeInsufficientCredits})
+		default:
+			json.NewEncoder(w).Encode(VerifyResponse{Valid: true, Carrier: key})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestClient_APIKeys(t *testing.T) {
+	verify := func(t *testing.T, client *Client) (*VerifyResponse, error) {
+		t.Helper()
+		return client.VerifyWithContext(context.Background(), VerifyRequest{Number: "+244921204020"})
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		server, seen := newKeyServer(t, nil)
+		client := New("key-a", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, APIKeys: []string{"key-b", "key-c"}})
+
+		for i := 0; i < 6; i++ {
+			_, err := verify(t, client)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"key-a", "key-b", "key-c", "key-a", "key-b", "key-c"}, seen())
+	})
+
+	t.Run("benches rate limited keys and retries with another", func(t *testing.T) {
+		server, seen := newKeyServer(t, map[string]int{"key-b": http.StatusTooManyRequests})
+		client := New("key-a", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, APIKeys: []string{"key-b", "key-c"}})
+
+		for i := 0; i < 4; i++ {
+			resp, err := verify(t, client)
+			require.NoError(t, err)
+			assert.NotEqual(t, "key-b", resp.Carrier)
+		}
+		assert.Equal(t, []string{"key-a", "key-b", "key-c", "key-a", "key-c"}, seen())
+
+		health := client.KeyHealth()
+		require.Len(t, health, 3)
+		assert.True(t, health[0].Available)
+		assert.False(t, health[1].Available)
+		assert.Equal(t, ErrorCodeRateLimitExceeded, health[1].BenchReason)
+		assert.Equal(t, int64(1), health[1].RateLimited)
+		assert.Equal(t, int64(2), health[2].Requests)
+	})
+
+	t.Run("benches revoked keys", func(t *testing.T) {
+		server, seen := newKeyServer(t, map[string]int{"key-a": http.StatusUnauthorized})
+		client := New("key-a", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, APIKeys: []string{"key-b"}})
+
+		for i := 0; i < 4; i++ {
+			resp, err := verify(t, client)
+			require.NoError(t, err)
+			assert.Equal(t, "key-b", resp.Carrier)
+		}
+		// the revoked key costs one extra round trip, not one per request
+		assert.Equal(t, []string{"key-a", "key-b", "key-b", "key-b", "key-b"}, seen())
+
+		health := client.KeyHealth()
+		require.Len(t, health, 2)
+		assert.False(t, health[0].Available)
+		assert.Equal(t, ErrorCodeUnauthorized, health[0].BenchReason)
+	})
+
+	t.Run("all keys benched", func(t *testing.T) {
+		server, _ := newKeyServer(t, map[string]int{"key-a": http.StatusPaymentRequired, "key-b": http.StatusPaymentRequired})
+		client := New("key-a", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, APIKeys: []string{"key-b"}})
+
+		_, err := verify(t, client)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, ErrorCodeInsufficientCredits, apiErr.Code)
+
+		_, err = verify(t, client)
+		assert.ErrorIs(t, err, ErrAllKeysBenched)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	t.Run("single key is not retried", func(t *testing.T) {
+		server, seen := newKeyServer(t, map[string]int{"key-a": http.StatusTooManyRequests})
+		client := New("key-a", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		_, err := verify(t, client)
+		require.Error(t, err)
+		assert.Equal(t, []string{"key-a"}, seen())
+		assert.Nil(t, client.KeyHealth())
+	})
+
+	t.Run("conflicting options", func(t *testing.T) {
+		client := New("key-a", Config{APIKeys: []string{"key-b"}, Credentials: StaticCredentials("key-c")})
+		_, err := verify(t, client)
+		assert.ErrorIs(t, err, errKeyPoolWithCredentials)
+	})
+}
+
+func TestKeyPool(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	rateLimited := &APIError{StatusCode: http.StatusTooManyRequests}
+
+	t.Run("bench expires", func(t *testing.T) {
+		pool := NewKeyPool([]string{"key-a", "key-b"}, KeyPoolConfig{RateLimitBench: time.Minute})
+		pool.now = func() time.Time { return now }
+
+		pool.ReportResult(ctx, "key-a", rateLimited)
+		for i := 0; i < 3; i++ {
+			key, err := pool.APIKey(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "key-b", key)
+		}
+
+		pool.now = func() time.Time { return now.Add(time.Minute) }
+		keys := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			key, _ := pool.APIKey(ctx)
+			keys[key] = true
+		}
+		assert.Len(t, keys, 2)
+	})
+
+	t.Run("least recently rate limited", func(t *testing.T) {
+		pool := NewKeyPool([]string{"key-a", "key-b", "key-c"}, KeyPoolConfig{
+			Strategy:       KeyStrategyLeastRecentlyRateLimited,
+			RateLimitBench: time.Second,
+		})
+		clock := now
+		pool.now = func() time.Time { return clock }
+
+		pool.ReportResult(ctx, "key-a", rateLimited)
+		clock = clock.Add(time.Minute)
+		pool.ReportResult(ctx, "key-b", rateLimited)
+		pool.ReportResult(ctx, "key-c", rateLimited)
+		clock = clock.Add(time.Minute)
+
+		// key-a was limited the longest time ago
+		key, err := pool.APIKey(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "key-a", key)
+
+		// between equally limited keys, the least used wins
+		pool.ReportResult(ctx, "key-a", rateLimited)
+		pool.ReportResult(ctx, "key-b", nil)
+		clock = clock.Add(time.Minute)
+		key, _ = pool.APIKey(ctx)
+		assert.Equal(t, "key-c", key)
+	})
+
+	t.Run("ignores empty and duplicate keys", func(t *testing.T) {
+		pool := NewKeyPool([]string{"", "key-a", " key-a ", "key-b"})
+		assert.Len(t, pool.Health(), 2)
+
+		_, err := NewKeyPool(nil).APIKey(ctx)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	t.Run("keys are masked", func(t *testing.T) {
+		pool := NewKeyPool([]string{"sk_live_0123456789abcdef", "short"})
+		health := pool.Health()
+		assert.Equal(t, "****cdef", health[0].Key)
+		assert.Equal(t, "****", health[1].Key)
+
+		formatted := fmt.Sprintf("%v %+v %#v", pool, pool, pool)
+		assert.NotContains(t, formatted, "0123456789")
+	})
+}
@@ -0,0 +1,293 @@
+package checkhim
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Locale identifies the language of an end-user message (e.g. "pt-PT")
+type Locale string
+
+// Locales shipped with the default message catalog
+const (
+	LocalePortuguesePortugal Locale = "pt-PT"
+	LocalePortugueseBrazil   Locale = "pt-BR"
+	LocaleEnglish            Locale = "en"
+	LocaleFrench             Locale = "fr"
+	LocaleSpanish            Locale = "es"
+)
+
+// defaultRegions maps a bare language to the locale used when no region is
+// given, so "pt" resolves to the European Portuguese messages
+var defaultRegions = map[string]Locale{
+	"pt": LocalePortuguesePortugal,
+}
+
+// ParseLocale normalizes a locale tag such as "pt_br", "PT-br" or an
+// Accept-Language header value ("pt-BR,pt;q=0.9,en;q=0.8") to the canonical
+// form used by the message catalog. Only the first tag is considered.
+func ParseLocale(tag string) Locale {
+	if i := strings.IndexAny(tag, ",;"); i >= 0 {
+		tag = tag[:i]
+	}
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return ""
+	}
+
+	parts := strings.SplitN(tag, "-", 2)
+	lang := strings.ToLower(parts[0])
+	if len(parts) == 1 {
+		return Locale(lang)
+	}
+	return Locale(lang + "-" + strings.ToUpper(parts[1]))
+}
+
+// MessageCatalog holds end-user safe messages keyed by locale and error code.
+// Messages are looked up by error code first and then by error category, so
+// applications only need to translate the codes they care about.
+//
+// A MessageCatalog is safe for concurrent use.
+type MessageCatalog struct {
+	mu         sync.RWMutex
+	codes      map[Locale]map[string]string
+	categories map[Locale]map[ErrorCategory]string
+	fallback   Locale
+}
+
+// NewMessageCatalog returns a catalog preloaded with the SDK translations.
+// Use Set and SetCategory to override or extend them.
+func NewMessageCatalog() *MessageCatalog {
+	c := &MessageCatalog{
+		codes:      make(map[Locale]map[string]string),
+		categories: make(map[Locale]map[ErrorCategory]string),
+		fallback:   LocaleEnglish,
+	}
+	for locale, messages := range defaultCodeMessages {
+		for code, message := range messages {
+			c.Set(locale, code, message)
+		}
+	}
+	for locale, messages := range defaultCategoryMessages {
+		for category, message := range messages {
+			c.SetCategory(locale, category, message)
+		}
+	}
+	return c
+}
+
+// ErrorCodeNumberRequired is the code to look up when the user submitted no
+// phone number. The API reports a missing number as invalid_request, whose
+// message is generic as the code also covers malformed requests.
+const ErrorCodeNumberRequired = "number_required"
+
+// DefaultMessages is the catalog used by APIError.LocalizedMessage
+var DefaultMessages = NewMessageCatalog()
+
+// Set registers the message shown for an error code in a locale
+func (c *MessageCatalog) Set(locale Locale, code, message string) {
+	locale = ParseLocale(string(locale))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.codes[locale] == nil {
+		c.codes[locale] = make(map[string]string)
+	}
+	c.codes[locale][normalizeCode(code)] = message
+}
+
+// SetCategory registers the message shown for every error of a category in a
+// locale that has no code-specific message
+func (c *MessageCatalog) SetCategory(locale Locale, category ErrorCategory, message string) {
+	locale = ParseLocale(string(locale))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.categories[locale] == nil {
+		c.categories[locale] = make(map[ErrorCategory]string)
+	}
+	c.categories[locale][category] = message
+}
+
+// SetFallback sets the locale used when a message is missing in the requested
+// locale. It defaults to English.
+func (c *MessageCatalog) SetFallback(locale Locale) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallback = ParseLocale(string(locale))
+}
+
+// Message returns the message for an error code in the given locale
+func (c *MessageCatalog) Message(locale Locale, code string) string {
+	return c.ForError(locale, &APIError{Code: code})
+}
+
+// ForError returns the end-user message for err in the given locale. Errors
+// that are not an *APIError are reported with the generic server message.
+//
+// The locale is resolved in order: exact match ("pt-BR"), language default
+// ("pt" → "pt-PT"), bare language ("pt"), the fallback locale, then English.
+// Each locale is searched for a message for the code, then for its category,
+// before moving to the next one.
+// The technical APIError.Message is never returned.
+func (c *MessageCatalog) ForError(locale Locale, err error) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = &APIError{Code: ErrorCodeServiceUnavailable}
+	}
+
+	code := normalizeCode(apiErr.Code)
+	category := apiErr.Category()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// a locale's category message wins over a code message of a later locale
+	candidates := c.candidates(ParseLocale(string(locale)))
+	for _, l := range candidates {
+		if message, ok := c.codes[l][code]; ok {
+			return message
+		}
+		if message, ok := c.categories[l][category]; ok {
+			return message
+		}
+	}
+	for _, l := range candidates {
+		if message, ok := c.categories[l][CategoryUnknown]; ok {
+			return message
+		}
+	}
+	// never the technical apiErr.Message, which is not meant for end users
+	return defaultCategoryMessages[LocaleEnglish][CategoryUnknown]
+}
+
+// candidates returns the locales to try for a requested locale, most specific
+// first
+func (c *MessageCatalog) candidates(locale Locale) []Locale {
+	var out []Locale
+	add := func(l Locale) {
+		if l == "" {
+			return
+		}
+		for _, existing := range out {
+			if existing == l {
+				return
+			}
+		}
+		out = append(out, l)
+	}
+
+	add(locale)
+	lang := strings.SplitN(string(locale), "-", 2)[0]
+	add(defaultRegions[lang])
+	add(Locale(lang))
+	add(c.fallback)
+	add(LocaleEnglish)
+	return out
+}
+
+// LocalizedMessage returns a non-technical message describing the error in
+// the given locale, suitable for showing to end users. It uses
+// DefaultMessages.
+func (e *APIError) LocalizedMessage(locale Locale) string {
+	return DefaultMessages.ForError(locale, e)
+}
+
+var defaultCodeMessages = map[Locale]map[string]string{
+	LocaleEnglish: {
+		ErrorCodeInvalidNumber:             "This phone number is not valid. Please check it and try again.",
+		ErrorCodeNumberRequired:            "Please enter a phone number.",
+		ErrorCodeRejectedFormat:            "This phone number is not valid. Please check it and try again.",
+		ErrorCodeRejectedPrefixMissing:     "Please include the country code, for example +244.",
+		ErrorCodeRejectedSubscriberAbsent:  "We could not reach this phone. Make sure it is switched on and try again.",
+		ErrorCodeRejectedUnknownSubscriber: "This phone number is not in service.",
+		ErrorCodeRateLimitExceeded:         "Too many attempts. Please wait a moment and try again.",
+	},
+	LocalePortuguesePortugal: {
+		ErrorCodeInvalidNumber:             "Este número de telemóvel não é válido. Verifique-o e tente novamente.",
+		ErrorCodeNumberRequired:            "Introduza um número de telemóvel.",
+		ErrorCodeRejectedFormat:            "Este número de telemóvel não é válido. Verifique-o e tente novamente.",
+		ErrorCodeRejectedPrefixMissing:     "Inclua o indicativo do país, por exemplo +351.",
+		ErrorCodeRejectedSubscriberAbsent:  "Não foi possível contactar este telemóvel. Confirme que está ligado e tente novamente.",
+		ErrorCodeRejectedUnknownSubscriber: "Este número de telemóvel não está atribuído.",
+		ErrorCodeRateLimitExceeded:         "Demasiadas tentativas. Aguarde um momento e tente novamente.",
+	},
+	LocalePortugueseBrazil: {
+		ErrorCodeInvalidNumber:             "Este número de celular não é válido. Confira e tente novamente.",
+		ErrorCodeNumberRequired:            "Informe um número de celular.",
+		ErrorCodeRejectedFormat:            "Este número de celular não é válido. Confira e tente novamente.",
+		ErrorCodeRejectedPrefixMissing:     "Inclua o código do país, por exemplo +55.",
+		ErrorCodeRejectedSubscriberAbsent:  "Não conseguimos contatar este celular. Verifique se ele está ligado e tente novamente.",
+		ErrorCodeRejectedUnknownSubscriber: "Este número de celular não existe.",
+		ErrorCodeRateLimitExceeded:         "Muitas tentativas. Aguarde um instante e tente novamente.",
+	},
+	LocaleFrench: {
+		ErrorCodeInvalidNumber:             "Ce numéro de téléphone n'est pas valide. Vérifiez-le et réessayez.",
+		ErrorCodeNumberRequired:            "Veuillez saisir un numéro de téléphone.",
+		ErrorCodeRejectedFormat:            "Ce numéro de téléphone n'est pas valide. Vérifiez-le et réessayez.",
+		ErrorCodeRejectedPrefixMissing:     "Veuillez inclure l'indicatif du pays, par exemple +33.",
+		ErrorCodeRejectedSubscriberAbsent:  "Nous n'avons pas pu joindre ce téléphone. Vérifiez qu'il est allumé et réessayez.",
+		ErrorCodeRejectedUnknownSubscriber: "Ce numéro de téléphone n'est pas attribué.",
+		ErrorCodeRateLimitExceeded:         "Trop de tentatives. Patientez un instant et réessayez.",
+	},
+	LocaleSpanish: {
+		ErrorCodeInvalidNumber:             "Este número de teléfono no es válido. Revísalo e inténtalo de nuevo.",
+		ErrorCodeNumberRequired:            "Introduce un número de teléfono.",
+		ErrorCodeRejectedFormat:            "Este número de teléfono no es válido. Revísalo e inténtalo de nuevo.",
+		ErrorCodeRejectedPrefixMissing:     "Incluye el prefijo del país, por ejemplo +34.",
+		ErrorCodeRejectedSubscriberAbsent:  "No pudimos contactar con este teléfono. Comprueba que está encendido e inténtalo de nuevo.",
+		ErrorCodeRejectedUnknownSubscriber: "Este número de teléfono no está en servicio.",
+		ErrorCodeRateLimitExceeded:         "Demasiados intentos. Espera un momento e inténtalo de nuevo.",
+	},
+}
+
+var defaultCategoryMessages = map[Locale]map[ErrorCategory]string{
+	LocaleEnglish: {
+		CategoryInput:   "This phone number is not valid. Please check it and try again.",
+		CategoryNetwork: "We could not reach this phone number. Please use a different number.",
+		CategoryRequest: "Your request could not be processed. Please try again.",
+		CategoryAuth:    "Phone verification is temporarily unavailable. Please try again later.",
+		CategoryQuota:   "Phone verification is temporarily unavailable. Please try again later.",
+		CategoryServer:  "Phone verification is temporarily unavailable. Please try again later.",
+		CategoryUnknown: "We could not verify this phone number. Please try again later.",
+	},
+	LocalePortuguesePortugal: {
+		CategoryInput:   "Este número de telemóvel não é válido. Verifique-o e tente novamente.",
+		CategoryNetwork: "Não foi possível contactar este número. Utilize outro número.",
+		CategoryRequest: "Não foi possível processar o pedido. Tente novamente.",
+		CategoryAuth:    "A verificação do número está temporariamente indisponível. Tente mais tarde.",
+		CategoryQuota:   "A verificação do número está temporariamente indisponível. Tente mais tarde.",
+		CategoryServer:  "A verificação do número está temporariamente indisponível. Tente mais tarde.",
+		CategoryUnknown: "Não foi possível verificar este número. Tente mais tarde.",
+	},
+	LocalePortugueseBrazil: {
+		CategoryInput:   "Este número de celular não é válido. Confira e tente novamente.",
+		CategoryNetwork: "Não conseguimos contatar este número. Use outro número.",
+		CategoryRequest: "Não foi possível processar a solicitação. Tente novamente.",
+		CategoryAuth:    "A verificação do número está temporariamente indisponível. Tente novamente mais tarde.",
+		CategoryQuota:   "A verificação do número está temporariamente indisponível. Tente novamente mais tarde.",
+		CategoryServer:  "A verificação do número está temporariamente indisponível. Tente novamente mais tarde.",
+		CategoryUnknown: "Não conseguimos verificar este número. Tente novamente mais tarde.",
+	},
+	LocaleFrench: {
+		CategoryInput:   "Ce numéro de téléphone n'est pas valide. Vérifiez-le et réessayez.",
+		CategoryNetwork: "Nous n'avons pas pu joindre ce numéro. Veuillez utiliser un autre numéro.",
+		CategoryRequest: "Votre demande n'a pas pu être traitée. Veuillez réessayer.",
+		CategoryAuth:    "La vérification du numéro est momentanément indisponible. Réessayez plus tard.",
+		CategoryQuota:   "La vérification du numéro est momentanément indisponible. Réessayez plus tard.",
+		CategoryServer:  "La vérification du numéro est momentanément indisponible. Réessayez plus tard.",
+		CategoryUnknown: "Nous n'avons pas pu vérifier ce numéro. Réessayez plus tard.",
+	},
+	LocaleSpanish: {
+		CategoryInput:   "Este número de teléfono no es válido. Revísalo e inténtalo de nuevo.",
+		CategoryNetwork: "No pudimos contactar con este número. Usa otro número.",
+		CategoryRequest: "No se pudo procesar la solicitud. Inténtalo de nuevo.",
+		CategoryAuth:    "La verificación del número no está disponible en este momento. Inténtalo más tarde.",
+		CategoryQuota:   "La verificación del número no está disponible en este momento. Inténtalo más tarde.",
+		CategoryServer:  "La verificación del número no está disponible en este momento. Inténtalo más tarde.",
+		CategoryUnknown: "No pudimos verificar este número. Inténtalo más tarde.",
+	},
+}
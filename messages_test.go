@@ -0,0 +1,128 @@
+package checkhim
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Locale
+	}{
+		{"pt-PT", LocalePortuguesePortugal},
+		{"pt_br", LocalePortugueseBrazil},
+		{"PT-br", LocalePortugueseBrazil},
+		{"EN", LocaleEnglish},
+		{"fr-FR,fr;q=0.9,en;q=0.8", "fr-FR"},
+		{" es ", LocaleSpanish},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseLocale(tt.in))
+		})
+	}
+}
+
+func TestAPIError_LocalizedMessage(t *testing.T) {
+	err := &APIError{StatusCode: 400, Code: ErrorCodeRejectedSubscriberAbsent, Message: "verification failed: absent subscriber (code: 27)"}
+
+	t.Run("code specific message per locale", func(t *testing.T) {
+		assert.Contains(t, err.LocalizedMessage(LocalePortuguesePortugal), "telemóvel")
+		assert.Contains(t, err.LocalizedMessage(LocalePortugueseBrazil), "celular")
+		assert.Contains(t, err.LocalizedMessage(LocaleEnglish), "switched on")
+		assert.Contains(t, err.LocalizedMessage(LocaleFrench), "allumé")
+		assert.Contains(t, err.LocalizedMessage(LocaleSpanish), "encendido")
+	})
+
+	t.Run("never exposes the technical message", func(t *testing.T) {
+		for _, locale := range []Locale{LocalePortuguesePortugal, LocalePortugueseBrazil, LocaleEnglish, LocaleFrench, LocaleSpanish} {
+			assert.NotContains(t, err.LocalizedMessage(locale), "code: 27")
+		}
+	})
+
+	t.Run("bare language resolves to default region", func(t *testing.T) {
+		assert.Equal(t, err.LocalizedMessage(LocalePortuguesePortugal), err.LocalizedMessage("pt"))
+	})
+
+	t.Run("region falls back to language", func(t *testing.T) {
+		assert.Equal(t, err.LocalizedMessage(LocaleFrench), err.LocalizedMessage("fr-CA"))
+	})
+
+	t.Run("unknown locale falls back to English", func(t *testing.T) {
+		assert.Equal(t, err.LocalizedMessage(LocaleEnglish), err.LocalizedMessage("ja-JP"))
+	})
+
+	t.Run("code without translation uses the category message", func(t *testing.T) {
+		netErr := &APIError{StatusCode: 400, Code: ErrorCodeRejectedNetwork}
+		assert.Equal(t, defaultCategoryMessages[LocaleSpanish][CategoryNetwork], netErr.LocalizedMessage(LocaleSpanish))
+	})
+
+	t.Run("invalid request is not about the number", func(t *testing.T) {
+		invalid := &APIError{StatusCode: 400, Code: ErrorCodeInvalidRequest, Message: "unsupported verification type"}
+		assert.Equal(t, defaultCategoryMessages[LocalePortugueseBrazil][CategoryRequest], invalid.LocalizedMessage(LocalePortugueseBrazil))
+		assert.NotEqual(t, (&APIError{Code: ErrorCodeNumberRequired}).LocalizedMessage(LocaleEnglish), invalid.LocalizedMessage(LocaleEnglish))
+	})
+
+	t.Run("missing number", func(t *testing.T) {
+		_, err := New("test-api-key").Verify(VerifyRequest{})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "Please enter a phone number.", apiErr.LocalizedMessage(LocaleEnglish))
+	})
+
+	t.Run("unknown code uses the generic message", func(t *testing.T) {
+		unknown := &APIError{StatusCode: 418, Code: "teapot"}
+		assert.Equal(t, defaultCategoryMessages[LocaleEnglish][CategoryUnknown], unknown.LocalizedMessage(LocaleEnglish))
+	})
+}
+
+func TestMessageCatalog(t *testing.T) {
+	t.Run("override and extend", func(t *testing.T) {
+		catalog := NewMessageCatalog()
+		catalog.Set(LocaleEnglish, ErrorCodeRejectedNetwork, "Please use another operator.")
+		catalog.Set("de", ErrorCodeInvalidNumber, "Diese Telefonnummer ist ungültig.")
+		catalog.SetCategory("de", CategoryUnknown, "Bitte später erneut versuchen.")
+
+		assert.Equal(t, "Please use another operator.", catalog.Message(LocaleEnglish, "rejected_network"))
+		assert.Equal(t, "Diese Telefonnummer ist ungültig.", catalog.Message("de-DE", ErrorCodeInvalidNumber))
+		assert.Equal(t, "Bitte später erneut versuchen.", catalog.Message("de", "teapot"))
+
+		// The default catalog is not affected
+		assert.NotEqual(t, "Please use another operator.", DefaultMessages.Message(LocaleEnglish, ErrorCodeRejectedNetwork))
+	})
+
+	t.Run("English override does not shadow translated categories", func(t *testing.T) {
+		catalog := NewMessageCatalog()
+		catalog.Set(LocaleEnglish, ErrorCodeRejectedNetwork, "Please use another operator.")
+
+		assert.Equal(t, defaultCategoryMessages[LocalePortuguesePortugal][CategoryNetwork], catalog.Message(LocalePortuguesePortugal, ErrorCodeRejectedNetwork))
+		assert.Equal(t, "Please use another operator.", catalog.Message("ja", ErrorCodeRejectedNetwork))
+	})
+
+	t.Run("fallback locale", func(t *testing.T) {
+		catalog := NewMessageCatalog()
+		catalog.SetFallback(LocalePortugueseBrazil)
+
+		assert.Equal(t, catalog.Message(LocalePortugueseBrazil, ErrorCodeInvalidNumber), catalog.Message("ja", ErrorCodeInvalidNumber))
+	})
+
+	t.Run("fallback locale without messages", func(t *testing.T) {
+		catalog := NewMessageCatalog()
+		catalog.SetFallback("de")
+
+		err := &APIError{StatusCode: 418, Code: "teapot", Message: "upstream said: stack trace"}
+		assert.Equal(t, defaultCategoryMessages[LocaleEnglish][CategoryUnknown], catalog.ForError("ja", err))
+		assert.Equal(t, catalog.Message(LocaleEnglish, ErrorCodeInvalidNumber), catalog.Message("ja", ErrorCodeInvalidNumber))
+	})
+
+	t.Run("non API errors", func(t *testing.T) {
+		msg := DefaultMessages.ForError(LocaleEnglish, errors.New("dial tcp: connection refused"))
+		assert.Equal(t, defaultCategoryMessages[LocaleEnglish][CategoryServer], msg)
+	})
+}
@@ -0,0 +1,85 @@
+package checkhim
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Doer sends an HTTP request and returns its response. *http.Client
+// implements Doer.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to the Doer interface
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req)
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer to inspect or modify outgoing requests and incoming
+// responses. Middleware runs after the client has set its own headers, so it
+// sees the request exactly as it is sent.
+//
+//	logRequests := func(next checkhim.Doer) checkhim.Doer {
+//		return checkhim.DoerFunc(func(req *http.Request) (*http.Response, error) {
+//			resp, err := next.Do(req)
+//			log.Printf("%s %s: %v", req.Method, req.URL.Path, err)
+//			return resp, err
+//		})
+//	}
+type Middleware func(next Doer) Doer
+
+// chain wraps base with mws so that mws[0] is the outermost middleware
+func chain(base Doer, mws []Middleware) Doer {
+	for i := len(mws) - 1; i >= 0; i-- {
+		base = mws[i](base)
+	}
+	return base
+}
+
+// DefaultRequestIDHeader is the header used by RequestIDMiddleware when no
+// header name is given
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware sets a unique request ID header on every request that
+// does not carry one yet. header defaults to DefaultRequestIDHeader and
+// generate defaults to NewIdempotencyKey.
+func RequestIDMiddleware(header string, generate func() string) Middleware {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	if generate == nil {
+		generate = NewIdempotencyKey
+	}
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) == "" {
+				req.Header.Set(header, generate())
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// UserAgentMiddleware appends suffix to the User-Agent header of every
+// request, e.g. "billing-service/2.3".
+func UserAgentMiddleware(suffix string) Middleware {
+	suffix = strings.TrimSpace(suffix)
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if suffix != "" {
+				ua := req.Header.Get("User-Agent")
+				if ua != "" {
+					ua += " "
+				}
+				req.Header.Set("User-Agent", ua+suffix)
+			}
+			return next.Do(req)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		json.NewEncoder(w).Encode(VerifyResponse{Carrier: "UNITEL", Valid: true})
+	}))
+	defer server.Close()
+
+	t.Run("runs in order around the round trip", func(t *testing.T) {
+		var calls []string
+		trace := func(name string) Middleware {
+			return func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, name+":before")
+					resp, err := next.Do(req)
+					calls = append(calls, name+":after")
+					return resp, err
+				})
+			}
+		}
+
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Middleware:             []Middleware{trace("outer"), trace("inner")},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"outer:before", "inner:before", "inner:after", "outer:after"}, calls)
+	})
+
+	t.Run("can mutate requests and inspect responses", func(t *testing.T) {
+		var status int
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Middleware: []Middleware{func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					req.Header.Set("X-Corp-Audit", "signed")
+					resp, err := next.Do(req)
+					if resp != nil {
+						status = resp.StatusCode
+					}
+					return resp, err
+				})
+			}},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "signed", received.Get("X-Corp-Audit"))
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("can short-circuit the request", func(t *testing.T) {
+		blocked := errors.New("blocked by policy")
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Middleware: []Middleware{func(next Doer) Doer {
+				return DoerFunc(func(req *http.Request) (*http.Response, error) {
+					return nil, blocked
+				})
+			}},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, blocked)
+	})
+
+	t.Run("request ID injection", func(t *testing.T) {
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Middleware:             []Middleware{RequestIDMiddleware("", func() string { return "req-1" })},
+		})
+
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+		assert.Equal(t, "req-1", received.Get(DefaultRequestIDHeader))
+
+		_, err = client.Verify(VerifyRequest{
+			Number:  "+244921204020",
+			Headers: http.Header{"X-Request-Id": {"caller-id"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "caller-id", received.Get(DefaultRequestIDHeader))
+	})
+
+	t.Run("request ID default generator", func(t *testing.T) {
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Middleware:             []Middleware{RequestIDMiddleware("X-Correlation-ID", nil)},
+		})
+
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+		assert.Len(t, received.Get("X-Correlation-ID"), 32)
+	})
+
+	t.Run("user agent suffix", func(t *testing.T) {
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Middleware:             []Middleware{UserAgentMiddleware("billing-service/2.3")},
+		})
+
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		ua := received.Get("User-Agent")
+		assert.True(t, strings.HasPrefix(ua, "checkhim-go-sdk/"), ua)
+		assert.True(t, strings.HasSuffix(ua, " billing-service/2.3"), ua)
+	})
+}
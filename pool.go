@@ -0,0 +1,358 @@
+package checkhim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/checkhim/go-sdk/internal/ratelimit"
+)
+
+// ErrNoTenant is returned by ClientPool when the context carries no tenant
+var ErrNoTenant = errors.New("checkhim: no tenant in context")
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant used by ClientPool
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored by WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// Tenant describes the CheckHim account of a tenant
+type Tenant struct {
+	// APIKey is the tenant's CheckHim API key
+	APIKey string
+
+	// Credentials supplies the key instead of APIKey (optional)
+	Credentials CredentialsProvider
+
+	// RateLimit overrides PoolConfig.RateLimit for this tenant (optional)
+	RateLimit float64
+
+	// Burst overrides PoolConfig.Burst for this tenant (optional)
+	Burst int
+
+	// Budget limits the tenant's billable verifications (optional). Each
+	// tenant needs its own Budget, or one tenant could use up the others'.
+	Budget *Budget
+}
+
+// PoolConfig configures a ClientPool
+type PoolConfig struct {
+	// Config is the base configuration of every tenant client. Its transport
+	// is built once and shared by all tenants; credentials, APIKeys,
+	// KeyStrategy and Budget are ignored, since they belong to a tenant (see
+	// Tenant.Budget).
+	Config Config
+
+	// Resolve returns the account of a tenant the first time it is used
+	// (required)
+	Resolve func(ctx context.Context, tenant string) (Tenant, error)
+
+	// RateLimit is the default number of requests per second allowed per
+	// tenant (optional, 0 means unlimited). Requests over the limit fail with
+	// an *APIError whose code is ErrorCodeRateLimitExceeded.
+	RateLimit float64
+
+	// Burst is the default number of requests a tenant may make at once
+	// (optional, defaults to 1 when RateLimit is set)
+	Burst int
+
+	// IdleTimeout evicts tenants unused for that long (optional, 0 keeps
+	// them forever). Eviction runs in the background until Close is called.
+	IdleTimeout time.Duration
+}
+
+// TenantStats reports the activity of a tenant
+type TenantStats struct {
+	// Requests is the number of API requests made
+	Requests int64
+
+	// Failures is the number of requests that failed or returned an error
+	// status
+	Failures int64
+
+	// RateLimited is the number of requests refused by the tenant's rate
+	// limit
+	RateLimited int64
+
+	// Created is when the tenant client was created
+	Created time.Time
+
+	// LastUsed is when the tenant client was last requested
+	LastUsed time.Time
+}
+
+// ClientPool lazily creates and caches a Client per tenant. All clients
+// share one HTTP transport, so connections to the API are reused across
+// tenants. It is safe for concurrent use.
+type ClientPool struct {
+	config     PoolConfig
+	httpClient *http.Client
+	configErr  error
+	now        func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*tenantEntry
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type tenantEntry struct {
+	client   *Client
+	created  time.Time
+	lastUsed atomic.Int64 // unix nanoseconds
+
+	requests    atomic.Int64
+	failures    atomic.Int64
+	rateLimited atomic.Int64
+}
+
+// NewClientPool returns a ClientPool. Configuration problems are reported by
+// the first call to Client.
+func NewClientPool(config PoolConfig) *ClientPool {
+	if config.Config.Timeout <= 0 {
+		config.Config.Timeout = DefaultTimeout
+	}
+
+	p := &ClientPool{
+		config:  config,
+		now:     time.Now,
+		tenants: make(map[string]*tenantEntry),
+	}
+
+	p.httpClient = config.Config.HTTPClient
+	if p.httpClient == nil {
+		p.httpClient, p.configErr = newHTTPClient(config.Config)
+	}
+	if config.Resolve == nil {
+		p.configErr = errors.New("checkhim: PoolConfig.Resolve is required")
+	}
+
+	if config.IdleTimeout > 0 {
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.evictLoop()
+	}
+	return p
+}
+
+// Client returns the client of the tenant carried by ctx, creating it on
+// first use
+func (p *ClientPool) Client(ctx context.Context) (*Client, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	return p.TenantClient(ctx, tenant)
+}
+
+// TenantClient returns the client of tenant, creating it on first use
+func (p *ClientPool) TenantClient(ctx context.Context, tenant string) (*Client, error) {
+	if p.configErr != nil {
+		return nil, p.configErr
+	}
+
+	p.mu.Lock()
+	entry, ok := p.tenants[tenant]
+	p.mu.Unlock()
+
+	if !ok {
+		// resolved without holding the lock so slow lookups of one tenant do
+		// not block the others
+		t, err := p.config.Resolve(ctx, tenant)
+		if err != nil {
+			return nil, fmt.Errorf("checkhim: failed to resolve tenant %s: %w", tenant, err)
+		}
+		created := p.newEntry(t)
+
+		p.mu.Lock()
+		if entry, ok = p.tenants[tenant]; !ok {
+			entry = created
+			p.tenants[tenant] = entry
+		}
+		p.mu.Unlock()
+	}
+
+	entry.lastUsed.Store(p.now().UnixNano())
+	return entry.client, nil
+}
+
+// Verify verifies a number with the client of the tenant carried by ctx
+func (p *ClientPool) Verify(ctx context.Context, req VerifyRequest) (*VerifyResponse, error) {
+	client, err := p.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.VerifyWithContext(ctx, req)
+}
+
+// newEntry creates the client of a tenant
+func (p *ClientPool) newEntry(t Tenant) *tenantEntry {
+	entry := &tenantEntry{created: p.now()}
+
+	rate, burst := p.config.RateLimit, p.config.Burst
+	if t.RateLimit > 0 {
+		rate = t.RateLimit
+	}
+	if t.Burst > 0 {
+		burst = t.Burst
+	}
+	var limiter *ratelimit.Limiter
+	if rate > 0 {
+		limiter = ratelimit.New(rate, burst)
+	}
+
+	config := p.config.Config
+	config.HTTPClient = p.httpClient
+	config.Credentials = t.Credentials
+	// the base config's keys belong to no tenant
+	config.APIKeys = nil
+	config.KeyStrategy = KeyStrategyRoundRobin
+	config.Budget = t.Budget
+	config.Middleware = append([]Middleware{entry.middleware(limiter)}, config.Middleware...)
+
+	entry.client = New(t.APIKey, config)
+	return entry
+}
+
+// middleware counts requests and applies the tenant's rate limit
+func (e *tenantEntry) middleware(limiter *ratelimit.Limiter) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if limiter != nil {
+				if ok, _ := limiter.Allow(); !ok {
+					e.rateLimited.Add(1)
+					return nil, &APIError{
+						StatusCode: http.StatusTooManyRequests,
+						Message:    "tenant rate limit exceeded",
+						Code:       ErrorCodeRateLimitExceeded,
+					}
+				}
+			}
+
+			e.requests.Add(1)
+			resp, err := next.Do(req)
+			if err != nil || resp.StatusCode >= 400 {
+				e.failures.Add(1)
+			}
+			return resp, err
+		})
+	}
+}
+
+// Stats returns the activity of every tenant in the pool
+func (p *ClientPool) Stats() map[string]TenantStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[string]TenantStats, len(p.tenants))
+	for tenant, entry := range p.tenants {
+		stats[tenant] = entry.stats()
+	}
+	return stats
+}
+
+// TenantStats returns the activity of a tenant, and false if the tenant is
+// not in the pool
+func (p *ClientPool) TenantStats(tenant string) (TenantStats, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.tenants[tenant]
+	if !ok {
+		return TenantStats{}, false
+	}
+	return entry.stats(), true
+}
+
+func (e *tenantEntry) stats() TenantStats {
+	return TenantStats{
+		Requests:    e.requests.Load(),
+		Failures:    e.failures.Load(),
+		RateLimited: e.rateLimited.Load(),
+		Created:     e.created,
+		LastUsed:    time.Unix(0, e.lastUsed.Load()),
+	}
+}
+
+// Tenants returns the tenants in the pool, sorted
+func (p *ClientPool) Tenants() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tenants := make([]string, 0, len(p.tenants))
+	for tenant := range p.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Evict removes a tenant from the pool, e.g. after its key changed. Its
+// client is created again on next use.
+func (p *ClientPool) Evict(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tenants, tenant)
+}
+
+// EvictIdle removes the tenants unused for longer than idle and returns how
+// many were removed
+func (p *ClientPool) EvictIdle(idle time.Duration) int {
+	cutoff := p.now().Add(-idle).UnixNano()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	evicted := 0
+	for tenant, entry := range p.tenants {
+		if entry.lastUsed.Load() < cutoff {
+			delete(p.tenants, tenant)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Close stops the background eviction. Clients already returned keep
+// working.
+func (p *ClientPool) Close() error {
+	if p.stop != nil {
+		p.closeOnce.Do(func() {
+			close(p.stop)
+			<-p.done
+		})
+	}
+	return nil
+}
+
+func (p *ClientPool) evictLoop() {
+	defer close(p.done)
+
+	interval := p.config.IdleTimeout / 2
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.EvictIdle(p.config.IdleTimeout)
+		}
+	}
+}
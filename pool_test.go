@@ -0,0 +1,272 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTenantServer echoes the API key of each verification in the carrier
+// field
+func newTenantServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if key == "revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid API key", Code: ErrorCodeUnauthorized})
+			return
+		}
+		json.NewEncoder(w).Encode(VerifyResponse{Valid: true, Carrier: key})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestPool(t *testing.T, config PoolConfig) (*ClientPool, *int32) {
+	t.Helper()
+	var resolves int32
+	config.Config.BaseURL = newTenantServer(t).URL
+	config.Config.AllowInsecureLocalhost = true
+	if config.Resolve == nil {
+		config.Resolve = func(ctx context.Context, tenant string) (Tenant, error) {
+			atomic.AddInt32(&resolves, 1)
+			if tenant == "unknown" {
+				return Tenant{}, errors.New("tenant not found")
+			}
+			return Tenant{APIKey: "key-" + tenant}, nil
+		}
+	}
+	pool := NewClientPool(config)
+	t.Cleanup(func() { pool.Close() })
+	return pool, &resolves
+}
+
+func TestClientPool(t *testing.T) {
+	t.Run("resolves the tenant from the context", func(t *testing.T) {
+		pool, resolves := newTestPool(t, PoolConfig{})
+
+		for _, tenant := range []string{"acme", "globex", "acme"} {
+			ctx := WithTenant(context.Background(), tenant)
+			resp, err := pool.Verify(ctx, VerifyRequest{Number: "+244921204020"})
+			require.NoError(t, err)
+			assert.Equal(t, "key-"+tenant, resp.Carrier)
+		}
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(resolves), "clients are cached")
+		assert.Equal(t, []string{"acme", "globex"}, pool.Tenants())
+	})
+
+	t.Run("clients share one transport", func(t *testing.T) {
+		pool, _ := newTestPool(t, PoolConfig{})
+
+		a, err := pool.TenantClient(context.Background(), "acme")
+		require.NoError(t, err)
+		b, err := pool.TenantClient(context.Background(), "globex")
+		require.NoError(t, err)
+
+		assert.NotSame(t, a, b)
+		assert.Same(t, a.httpClient, b.httpClient)
+	})
+
+	t.Run("concurrent first use creates one client", func(t *testing.T) {
+		pool, _ := newTestPool(t, PoolConfig{})
+
+		var wg sync.WaitGroup
+		clients := make([]*Client, 10)
+		for i := range clients {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				clients[i], _ = pool.TenantClient(context.Background(), "acme")
+			}(i)
+		}
+		wg.Wait()
+
+		for _, c := range clients {
+			assert.Same(t, clients[0], c)
+		}
+	})
+
+	t.Run("ignores the base config's keys", func(t *testing.T) {
+		pool, _ := newTestPool(t, PoolConfig{Config: Config{APIKeys: []string{"shared-1", "shared-2"}, KeyStrategy: KeyStrategyLeastRecentlyRateLimited}})
+
+		for i := 0; i < 3; i++ {
+			resp, err := pool.Verify(WithTenant(context.Background(), "acme"), VerifyRequest{Number: "+244921204020"})
+			require.NoError(t, err)
+			assert.Equal(t, "key-acme", resp.Carrier)
+		}
+		client, err := pool.TenantClient(context.Background(), "acme")
+		require.NoError(t, err)
+		assert.Nil(t, client.KeyHealth())
+	})
+
+	t.Run("tenant credentials with base keys", func(t *testing.T) {
+		pool, _ := newTestPool(t, PoolConfig{
+			Config: Config{APIKeys: []string{"shared-1"}},
+			Resolve: func(ctx context.Context, tenant string) (Tenant, error) {
+				return Tenant{Credentials: StaticCredentials("vault-" + tenant)}, nil
+			},
+		})
+
+		resp, err := pool.Verify(WithTenant(context.Background(), "acme"), VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+		assert.Equal(t, "vault-acme", resp.Carrier)
+	})
+
+	t.Run("budgets are per tenant", func(t *testing.T) {
+		budgets := map[string]*Budget{
+			"acme":   NewBudget(BudgetConfig{Hourly: 1}),
+			"globex": NewBudget(BudgetConfig{Hourly: 1}),
+		}
+		pool, _ := newTestPool(t, PoolConfig{
+			Config: Config{Budget: NewBudget(BudgetConfig{Hourly: 1})},
+			Resolve: func(ctx context.Context, tenant string) (Tenant, error) {
+				return Tenant{APIKey: "key-" + tenant, Budget: budgets[tenant]}, nil
+			},
+		})
+
+		acme := WithTenant(context.Background(), "acme")
+		_, err := pool.Verify(acme, VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+		_, err = pool.Verify(acme, VerifyRequest{Number: "+244921204020"})
+		assert.ErrorIs(t, err, ErrBudgetExceeded)
+
+		// acme's budget and the base config's do not apply to globex
+		_, err = pool.Verify(WithTenant(context.Background(), "globex"), VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		pool, _ := newTestPool(t, PoolConfig{})
+
+		_, err := pool.Verify(context.Background(), VerifyRequest{Number: "+244921204020"})
+		assert.ErrorIs(t, err, ErrNoTenant)
+
+		_, err = pool.Verify(WithTenant(context.Background(), "unknown"), VerifyRequest{Number: "+244921204020"})
+		assert.ErrorContains(t, err, "tenant not found")
+		assert.Empty(t, pool.Tenants(), "failed resolutions are not cached")
+
+		_, err = NewClientPool(PoolConfig{}).TenantClient(context.Background(), "acme")
+		assert.ErrorContains(t, err, "Resolve is required")
+	})
+}
+
+func TestClientPool_RateLimit(t *testing.T) {
+	pool, _ := newTestPool(t, PoolConfig{
+		RateLimit: 0.001,
+		Burst:     2,
+		Resolve: func(ctx context.Context, tenant string) (Tenant, error) {
+			if tenant == "vip" {
+				return Tenant{APIKey: "key-vip", Burst: 3}, nil
+			}
+			return Tenant{APIKey: "key-" + tenant}, nil
+		},
+	})
+
+	verify := func(tenant string) error {
+		_, err := pool.Verify(WithTenant(context.Background(), tenant), VerifyRequest{Number: "+244921204020"})
+		return err
+	}
+
+	require.NoError(t, verify("acme"))
+	require.NoError(t, verify("acme"))
+
+	err := verify("acme")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, ErrorCodeRateLimitExceeded, apiErr.Code)
+	assert.True(t, apiErr.IsTemporary())
+
+	// limits are per tenant and can be overridden
+	for i := 0; i < 3; i++ {
+		require.NoError(t, verify("vip"))
+	}
+
+	stats, ok := pool.TenantStats("acme")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), stats.Requests)
+	assert.Equal(t, int64(1), stats.RateLimited)
+	assert.Equal(t, int64(3), pool.Stats()["vip"].Requests)
+}
+
+func TestClientPool_Stats(t *testing.T) {
+	pool, _ := newTestPool(t, PoolConfig{
+		Resolve: func(ctx context.Context, tenant string) (Tenant, error) {
+			return Tenant{APIKey: tenant}, nil
+		},
+	})
+
+	_, err := pool.Verify(WithTenant(context.Background(), "revoked"), VerifyRequest{Number: "+244921204020"})
+	require.Error(t, err)
+	_, err = pool.Verify(WithTenant(context.Background(), "acme"), VerifyRequest{Number: "+244921204020"})
+	require.NoError(t, err)
+
+	stats := pool.Stats()
+	assert.Equal(t, int64(1), stats["revoked"].Requests)
+	assert.Equal(t, int64(1), stats["revoked"].Failures)
+	assert.Equal(t, int64(0), stats["acme"].Failures)
+	assert.False(t, stats["acme"].Created.IsZero())
+	assert.False(t, stats["acme"].LastUsed.Before(stats["acme"].Created))
+
+	_, ok := pool.TenantStats("nobody")
+	assert.False(t, ok)
+}
+
+func TestClientPool_Eviction(t *testing.T) {
+	t.Run("evicts idle tenants", func(t *testing.T) {
+		pool, resolves := newTestPool(t, PoolConfig{})
+		now := time.Unix(1700000000, 0)
+		pool.now = func() time.Time { return now }
+
+		ctx := context.Background()
+		_, err := pool.TenantClient(ctx, "acme")
+		require.NoError(t, err)
+		now = now.Add(10 * time.Minute)
+		_, err = pool.TenantClient(ctx, "globex")
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, pool.EvictIdle(5*time.Minute))
+		assert.Equal(t, []string{"globex"}, pool.Tenants())
+
+		_, err = pool.TenantClient(ctx, "acme")
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(resolves), "evicted tenants are resolved again")
+
+		pool.Evict("acme")
+		assert.Equal(t, []string{"globex"}, pool.Tenants())
+	})
+
+	t.Run("in the background", func(t *testing.T) {
+		pool, _ := newTestPool(t, PoolConfig{IdleTimeout: 10 * time.Millisecond})
+
+		_, err := pool.TenantClient(context.Background(), "acme")
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return len(pool.Tenants()) == 0 }, time.Second, 5*time.Millisecond)
+		require.NoError(t, pool.Close())
+		require.NoError(t, pool.Close())
+	})
+}
+
+func TestTenantFromContext(t *testing.T) {
+	_, ok := TenantFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = TenantFromContext(WithTenant(context.Background(), ""))
+	assert.False(t, ok)
+
+	tenant, ok := TenantFromContext(WithTenant(context.Background(), "acme"))
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+}
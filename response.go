@@ -0,0 +1,98 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// LineType is the kind of line behind a phone number
+type LineType string
+
+const (
+	LineTypeMobile LineType = "mobile"
+	LineTypeFixed  LineType = "fixed"
+	LineTypeVoIP   LineType = "voip"
+)
+
+// lineTypeAliases maps the spellings seen in API payloads to a LineType
+var lineTypeAliases = map[string]LineType{
+	"mobile":     LineTypeMobile,
+	"cell":       LineTypeMobile,
+	"cellular":   LineTypeMobile,
+	"fixed":      LineTypeFixed,
+	"fixed_line": LineTypeFixed,
+	"fixed-line": LineTypeFixed,
+	"fixedline":  LineTypeFixed,
+	"landline":   LineTypeFixed,
+	"voip":       LineTypeVoIP,
+}
+
+// String implements fmt.Stringer
+func (t LineType) String() string {
+	return string(t)
+}
+
+// UnmarshalJSON normalizes the known spellings of a line type; unknown values
+// are kept as received
+func (t *LineType) UnmarshalJSON(data []byte) error {
+	var raw *string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*t = ""
+		return nil
+	}
+
+	if known, ok := lineTypeAliases[strings.ToLower(strings.TrimSpace(*raw))]; ok {
+		*t = known
+		return nil
+	}
+	*t = LineType(*raw)
+	return nil
+}
+
+// NetworkInfo identifies a mobile network
+type NetworkInfo struct {
+	// Name is the network name (e.g. "UNITEL")
+	Name string `json:"name,omitempty"`
+
+	// MCC is the mobile country code (e.g. "631")
+	MCC string `json:"mcc,omitempty"`
+
+	// MNC is the mobile network code (e.g. "02")
+	MNC string `json:"mnc,omitempty"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the network's country
+	Country string `json:"country,omitempty"`
+}
+
+// RoamingInfo describes the roaming state of a handset
+type RoamingInfo struct {
+	// Active indicates whether the handset is currently roaming
+	Active bool `json:"active"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the visited country
+	Country string `json:"country,omitempty"`
+
+	// Network is the visited network
+	Network *NetworkInfo `json:"network,omitempty"`
+}
+
+// UnmarshalJSON decodes the response and keeps a copy of the payload in Raw
+func (r *VerifyResponse) UnmarshalJSON(data []byte) error {
+	type plain VerifyResponse
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*r = VerifyResponse(decoded)
+	r.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// IsRoaming reports whether the handset is known to be roaming
+func (r *VerifyResponse) IsRoaming() bool {
+	return r.Roaming != nil && r.Roaming.Active
+}
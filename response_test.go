@@ -0,0 +1,85 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyResponse_RichFields(t *testing.T) {
+	payload := `{
+		"carrier": "UNITEL",
+		"valid": true,
+		"status": "DELIVERED_TO_HANDSET",
+		"country": "AO",
+		"country_name": "Angola",
+		"line_type": "Mobile",
+		"ported": true,
+		"original_network": {"name": "MOVICEL", "mcc": "631", "mnc": "04", "country": "AO"},
+		"current_network": {"name": "UNITEL", "mcc": "631", "mnc": "02", "country": "AO"},
+		"roaming": {"active": true, "country": "PT", "network": {"name": "MEO", "mcc": "268", "mnc": "06"}},
+		"risk_score": 12
+	}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+	result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "AO", result.Country)
+	assert.Equal(t, "Angola", result.CountryName)
+	assert.Equal(t, LineTypeMobile, result.LineType)
+	assert.True(t, result.Ported)
+	require.NotNil(t, result.OriginalNetwork)
+	assert.Equal(t, "MOVICEL", result.OriginalNetwork.Name)
+	require.NotNil(t, result.CurrentNetwork)
+	assert.Equal(t, "02", result.CurrentNetwork.MNC)
+	assert.True(t, result.IsRoaming())
+	assert.Equal(t, "268", result.Roaming.Network.MCC)
+
+	t.Run("raw payload keeps unknown fields", func(t *testing.T) {
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(result.Raw, &raw))
+		assert.Equal(t, float64(12), raw["risk_score"])
+	})
+}
+
+func TestLineType_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want LineType
+	}{
+		{`"mobile"`, LineTypeMobile},
+		{`"LANDLINE"`, LineTypeFixed},
+		{`"fixed_line"`, LineTypeFixed},
+		{`"VoIP"`, LineTypeVoIP},
+		{`"toll_free"`, "toll_free"},
+		{`null`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var lt LineType
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &lt))
+			assert.Equal(t, tt.want, lt)
+		})
+	}
+}
+
+func TestVerifyResponse_MarshalOmitsRaw(t *testing.T) {
+	resp := VerifyResponse{Carrier: "UNITEL", Valid: true, Raw: json.RawMessage(`{"x":1}`)}
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"carrier":"UNITEL","valid":true}`, string(data))
+}
@@ -0,0 +1,340 @@
+package checkhim
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SandboxBaseURL is the base URL of the CheckHim sandbox. Sandbox
+// verifications are not billed and answer the SandboxNumber constants
+// deterministically.
+const SandboxBaseURL = "https://sandbox.checkhim.tech"
+
+// Números mágicos do sandbox. Each number always produces the same result in
+// the sandbox and in SandboxSimulator; any other well-formed number is
+// delivered to the handset.
+const (
+	// SandboxNumberDelivered is delivered to the handset (DELIVERED_TO_HANDSET)
+	SandboxNumberDelivered = "+244921000000"
+
+	// SandboxNumberDeliveredToOperator is delivered to the operator
+	// (DELIVERED_TO_OPERATOR)
+	SandboxNumberDeliveredToOperator = "+244921000001"
+
+	// SandboxNumberPending never leaves PENDING_ENROUTE, for testing
+	// timeouts
+	SandboxNumberPending = "+244921000002"
+
+	// SandboxNumberRejectedOperator is refused by the operator after
+	// acceptance (UNDELIVERABLE_REJECTED_OPERATOR)
+	SandboxNumberRejectedOperator = "+244921000003"
+
+	// SandboxNumberExpired gets no delivery report in time
+	// (EXPIRED_DLR_UNKNOWN)
+	SandboxNumberExpired = "+244921000004"
+
+	// The numbers below fail with an *APIError carrying the named code
+
+	SandboxNumberRejectedNetwork           = "+244921000111"
+	SandboxNumberRejectedPrefixMissing     = "+244921000112"
+	SandboxNumberRejectedFormat            = "+244921000113"
+	SandboxNumberRejectedSubscriberAbsent  = "+244921000114"
+	SandboxNumberRejectedUnknownSubscriber = "+244921000115"
+	SandboxNumberRejectedUndeliverable     = "+244921000116"
+	SandboxNumberUndeliverableNotDelivered = "+244921000117"
+	SandboxNumberTemporaryFailure          = "+244921000118"
+	SandboxNumberServiceUnavailable        = "+244921000119"
+	SandboxNumberInvalidNumber             = "+244921000120"
+	SandboxNumberRateLimitExceeded         = "+244921000121"
+	SandboxNumberInsufficientCredits       = "+244921000122"
+)
+
+// sandboxCarrier is the carrier reported for sandbox numbers
+const sandboxCarrier = "UNITEL"
+
+// SandboxScenario describes the result of a sandbox magic number
+type SandboxScenario struct {
+	// Number is the magic number, in E.164 format
+	Number string
+
+	// Status is the delivery status returned for the number, empty when the
+	// verification fails with Code
+	Status DeliveryStatus
+
+	// Code is the error code the verification fails with, empty when it
+	// succeeds with Status
+	Code string
+}
+
+var sandboxScenarios = []SandboxScenario{
+	{Number: SandboxNumberDelivered, Status: DeliveryStatusDeliveredToHandset},
+	{Number: SandboxNumberDeliveredToOperator, Status: DeliveryStatusDeliveredToOperator},
+	{Number: SandboxNumberPending, Status: DeliveryStatusPendingEnroute},
+	{Number: SandboxNumberRejectedOperator, Status: DeliveryStatusUndeliverableRejectedOperator},
+	{Number: SandboxNumberExpired, Status: DeliveryStatusExpiredDLRUnknown},
+	{Number: SandboxNumberRejectedNetwork, Code: ErrorCodeRejectedNetwork},
+	{Number: SandboxNumberRejectedPrefixMissing, Code: ErrorCodeRejectedPrefixMissing},
+	{Number: SandboxNumberRejectedFormat, Code: ErrorCodeRejectedFormat},
+	{Number: SandboxNumberRejectedSubscriberAbsent, Code: ErrorCodeRejectedSubscriberAbsent},
+	{Number: SandboxNumberRejectedUnknownSubscriber, Code: ErrorCodeRejectedUnknownSubscriber},
+	{Number: SandboxNumberRejectedUndeliverable, Code: ErrorCodeRejectedUndeliverable},
+	{Number: SandboxNumberUndeliverableNotDelivered, Code: ErrorCodeUndeliverableNotDelivered},
+	{Number: SandboxNumberTemporaryFailure, Code: ErrorCodeTemporaryFailure},
+	{Number: SandboxNumberServiceUnavailable, Code: ErrorCodeServiceUnavailable},
+	{Number: SandboxNumberInvalidNumber, Code: ErrorCodeInvalidNumber},
+	{Number: SandboxNumberRateLimitExceeded, Code: ErrorCodeRateLimitExceeded},
+	{Number: SandboxNumberInsufficientCredits, Code: ErrorCodeInsufficientCredits},
+}
+
+// SandboxScenarios returns the result of every magic number, in the order
+// of the SandboxNumber constants
+func SandboxScenarios() []SandboxScenario {
+	return append([]SandboxScenario(nil), sandboxScenarios...)
+}
+
+// LookupSandboxNumber returns the scenario of a magic number. The number may
+// be written with or without "+" or "00" and with spaces, dashes or
+// parentheses.
+func LookupSandboxNumber(number string) (SandboxScenario, bool) {
+	digits, ok := sandboxDigits(number)
+	if !ok {
+		return SandboxScenario{}, false
+	}
+	for _, scenario := range sandboxScenarios {
+		if scenario.Number[1:] == digits {
+			return scenario, true
+		}
+	}
+	return SandboxScenario{}, false
+}
+
+// sandboxDigits returns the digits of an international number, and false
+// when the number contains anything but digits and separators
+func sandboxDigits(number string) (string, bool) {
+	number = strings.TrimSpace(number)
+	if rest, ok := strings.CutPrefix(number, "+"); ok {
+		number = rest
+	} else if rest, ok := strings.CutPrefix(number, "00"); ok {
+		number = rest
+	}
+
+	var b strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+	digits := b.String()
+	return digits, len(digits) >= 8 && len(digits) <= 15
+}
+
+// sandboxIDPrefix starts the ID of asynchronous sandbox verifications, which
+// carries the number so GetVerification needs no state
+const sandboxIDPrefix = "sbx_"
+
+// SandboxSimulator reproduces the sandbox offline. It answers the verify and
+// verification lookup endpoints following the magic number scenarios, so
+// tests run without network access or an API key:
+//
+//	client := checkhim.New("test", checkhim.Config{
+//		Sandbox:    true,
+//		HTTPClient: checkhim.NewSandboxSimulator().Client(),
+//	})
+//
+// It can also be served with httptest.NewServer, as it implements
+// http.Handler. It is safe for concurrent use.
+type SandboxSimulator struct{}
+
+// NewSandboxSimulator returns a SandboxSimulator
+func NewSandboxSimulator() *SandboxSimulator {
+	return &SandboxSimulator{}
+}
+
+// Client returns an HTTP client whose requests are answered by the simulator
+func (s *SandboxSimulator) Client() *http.Client {
+	return &http.Client{Transport: s}
+}
+
+// isSandbox reports whether requests of a client built from config reach the
+// sandbox or the simulator, so they are not billed
+func isSandbox(config Config) bool {
+	if config.BaseURL == SandboxBaseURL {
+		return true
+	}
+	if config.HTTPClient != nil {
+		_, ok := config.HTTPClient.Transport.(*SandboxSimulator)
+		return ok
+	}
+	return false
+}
+
+// RoundTrip implements http.RoundTripper
+func (s *SandboxSimulator) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(req.Body, DefaultMaxResponseBytes))
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	status, header, respBody := s.respond(req.Method, req.URL.Path, req.Header, body)
+	header.Set("Content-Length", strconv.Itoa(len(respBody)))
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *SandboxSimulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, DefaultMaxResponseBytes))
+	if err != nil {
+		return
+	}
+
+	status, header, respBody := s.respond(r.Method, r.URL.Path, r.Header, body)
+	for name, values := range header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(status)
+	w.Write(respBody)
+}
+
+// respond computes the response to a request
+func (s *SandboxSimulator) respond(method, path string, header http.Header, body []byte) (int, http.Header, []byte) {
+	if token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); !ok || token == "" {
+		return sandboxError(ErrorCodeUnauthorized)
+	}
+
+	switch {
+	case path == "/api/verify":
+		if method != http.MethodPost {
+			return sandboxError(ErrorCodeInvalidRequest)
+		}
+		var req internalVerifyRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.Number == "" {
+			return sandboxError(ErrorCodeInvalidRequest)
+		}
+		return s.verify(req)
+
+	case strings.HasPrefix(path, "/api/verifications/"):
+		if method != http.MethodGet {
+			return sandboxError(ErrorCodeInvalidRequest)
+		}
+		number, ok := strings.CutPrefix(strings.TrimPrefix(path, "/api/verifications/"), sandboxIDPrefix)
+		if !ok {
+			return sandboxNotFound()
+		}
+		scenario, ok := sandboxScenario(number)
+		if !ok {
+			return sandboxNotFound()
+		}
+		resp := sandboxResponse(scenario)
+		resp.ID = sandboxIDPrefix + number
+		if scenario.Code != "" {
+			// failures accepted asynchronously end in the matching status
+			resp.Status = DeliveryStatus(scenario.Code)
+			resp.Valid = false
+		}
+		return sandboxJSON(http.StatusOK, resp)
+	}
+	return sandboxNotFound()
+}
+
+// verify answers the verify endpoint
+func (s *SandboxSimulator) verify(req internalVerifyRequest) (int, http.Header, []byte) {
+	scenario, ok := sandboxScenario(req.Number)
+	if !ok {
+		return sandboxError(ErrorCodeInvalidNumber)
+	}
+
+	if req.Async && (scenario.Code == "" || DeliveryStatus(scenario.Code).IsKnown()) {
+		digits, _ := sandboxDigits(req.Number)
+		return sandboxJSON(http.StatusAccepted, &VerifyResponse{
+			ID:        sandboxIDPrefix + digits,
+			Carrier:   sandboxCarrier,
+			Valid:     true,
+			Status:    DeliveryStatusPendingAccepted,
+			Country:   "AO",
+			Reference: req.Reference,
+			Metadata:  req.Metadata,
+		})
+	}
+	if scenario.Code != "" {
+		return sandboxError(scenario.Code)
+	}
+
+	resp := sandboxResponse(scenario)
+	resp.Reference = req.Reference
+	resp.Metadata = req.Metadata
+	return sandboxJSON(http.StatusOK, resp)
+}
+
+// sandboxScenario returns the scenario of a number, delivering numbers that
+// are not magic and refusing malformed ones
+func sandboxScenario(number string) (SandboxScenario, bool) {
+	if scenario, ok := LookupSandboxNumber(number); ok {
+		return scenario, true
+	}
+	digits, ok := sandboxDigits(number)
+	if !ok {
+		return SandboxScenario{}, false
+	}
+	return SandboxScenario{Number: "+" + digits, Status: DeliveryStatusDeliveredToHandset}, true
+}
+
+// sandboxResponse is the successful response of a scenario
+func sandboxResponse(scenario SandboxScenario) *VerifyResponse {
+	network := &NetworkInfo{Name: sandboxCarrier, MCC: "631", MNC: "02", Country: "AO"}
+	return &VerifyResponse{
+		Carrier:         sandboxCarrier,
+		Valid:           true,
+		Status:          scenario.Status,
+		Country:         "AO",
+		CountryName:     "Angola",
+		LineType:        LineTypeMobile,
+		OriginalNetwork: network,
+		CurrentNetwork:  network,
+	}
+}
+
+func sandboxError(code string) (int, http.Header, []byte) {
+	info, _ := LookupErrorCode(code)
+	status, header, body := sandboxJSON(info.HTTPStatus, ErrorResponse{Error: info.Description, Code: info.Code})
+	if code == ErrorCodeRateLimitExceeded {
+		header.Set("Retry-After", "1")
+	}
+	return status, header, body
+}
+
+func sandboxNotFound() (int, http.Header, []byte) {
+	return sandboxJSON(http.StatusNotFound, ErrorResponse{Error: "Verification not found", Code: ErrorCodeInvalidRequest})
+}
+
+func sandboxJSON(status int, v interface{}) (int, http.Header, []byte) {
+	body, _ := json.Marshal(v)
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	return status, header, body
+}
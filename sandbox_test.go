@@ -0,0 +1,188 @@
+package checkhim
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox(t *testing.T) {
+	newClient := func() *Client {
+		return New("test-api-key", Config{Sandbox: true, HTTPClient: NewSandboxSimulator().Client()})
+	}
+
+	t.Run("base URL", func(t *testing.T) {
+		assert.Equal(t, SandboxBaseURL, New("key", Config{Sandbox: true}).baseURL)
+		assert.Equal(t, DefaultBaseURL, New("key").baseURL)
+		assert.Equal(t, "https://sandbox.internal", New("key", Config{Sandbox: true, BaseURL: "https://sandbox.internal"}).baseURL)
+	})
+
+	t.Run("every scenario", func(t *testing.T) {
+		client := newClient()
+		for _, scenario := range SandboxScenarios() {
+			scenario := scenario
+			t.Run(scenario.Number, func(t *testing.T) {
+				resp, err := client.Verify(VerifyRequest{Number: scenario.Number})
+				if scenario.Code == "" {
+					require.NoError(t, err)
+					assert.Equal(t, scenario.Status, resp.Status)
+					assert.Equal(t, "UNITEL", resp.Carrier)
+					assert.Equal(t, "AO", resp.Country)
+					return
+				}
+
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				info, ok := LookupErrorCode(scenario.Code)
+				require.True(t, ok)
+				assert.Equal(t, info.Code, apiErr.Code)
+				assert.Equal(t, info.HTTPStatus, apiErr.StatusCode)
+			})
+		}
+	})
+
+	t.Run("outcomes", func(t *testing.T) {
+		client := newClient()
+		for number, want := range map[string]Outcome{
+			SandboxNumberDelivered:        OutcomeVerified,
+			SandboxNumberPending:          OutcomePending,
+			SandboxNumberRejectedOperator: OutcomeUnreachable,
+			"+351912345678":               OutcomeVerified,
+		} {
+			resp, err := client.Verify(VerifyRequest{Number: number})
+			require.NoError(t, err)
+			assert.Equal(t, want, resp.Outcome(), number)
+		}
+	})
+
+	t.Run("error classification", func(t *testing.T) {
+		client := newClient()
+		check := func(number string) *APIError {
+			_, err := client.Verify(VerifyRequest{Number: number})
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			return apiErr
+		}
+
+		assert.True(t, check(SandboxNumberRejectedNetwork).IsNetworkRelated())
+		assert.True(t, check(SandboxNumberRejectedFormat).IsNumberInvalid())
+		assert.True(t, check(SandboxNumberTemporaryFailure).IsTemporary())
+		assert.True(t, check(SandboxNumberRateLimitExceeded).IsQuota())
+		assert.True(t, check("not a number").IsNumberInvalid())
+	})
+
+	t.Run("echoes reference and metadata", func(t *testing.T) {
+		resp, err := newClient().Verify(VerifyRequest{
+			Number:    SandboxNumberDelivered,
+			Reference: "order-42",
+			Metadata:  map[string]string{"source": "test"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "order-42", resp.Reference)
+		assert.Equal(t, map[string]string{"source": "test"}, resp.Metadata)
+	})
+
+	t.Run("async", func(t *testing.T) {
+		client := newClient()
+		ctx := context.Background()
+		opts := PollOptions{InitialInterval: time.Millisecond}
+
+		v, err := client.VerifyAsync(ctx, VerifyRequest{Number: SandboxNumberDeliveredToOperator}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusPendingAccepted, v.Last().Status)
+		resp, err := v.Wait(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusDeliveredToOperator, resp.Status)
+
+		v, err = client.VerifyAsync(ctx, VerifyRequest{Number: SandboxNumberRejectedSubscriberAbsent}, opts)
+		require.NoError(t, err)
+		resp, err = v.Wait(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusRejectedSubscriberAbsent, resp.Status)
+		assert.Equal(t, OutcomeInvalid, resp.Outcome())
+
+		_, err = client.VerifyAsync(ctx, VerifyRequest{Number: SandboxNumberInsufficientCredits}, opts)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, ErrorCodeInsufficientCredits, apiErr.Code)
+
+		v, err = client.VerifyAsync(ctx, VerifyRequest{Number: SandboxNumberPending}, opts)
+		require.NoError(t, err)
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = v.Wait(waitCtx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		_, err = client.GetVerification(ctx, "ver_unknown")
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("not counted against a budget", func(t *testing.T) {
+		budget := NewBudget(BudgetConfig{Hourly: 1})
+		client := New("test-api-key", Config{Sandbox: true, HTTPClient: NewSandboxSimulator().Client(), Budget: budget})
+		for i := 0; i < 3; i++ {
+			_, err := client.Verify(VerifyRequest{Number: SandboxNumberDelivered})
+			require.NoError(t, err)
+		}
+
+		usage, err := budget.Usage(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), usage[BudgetHourly])
+	})
+
+	t.Run("requires an API key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, SandboxBaseURL+"/api/verify", strings.NewReader(`{"number":"+244921000000"}`))
+		rec := httptest.NewRecorder()
+		NewSandboxSimulator().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), ErrorCodeUnauthorized)
+	})
+
+	t.Run("served over HTTP", func(t *testing.T) {
+		server := httptest.NewServer(NewSandboxSimulator())
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		resp, err := client.Verify(VerifyRequest{Number: SandboxNumberDelivered})
+		require.NoError(t, err)
+		assert.Equal(t, DeliveryStatusDeliveredToHandset, resp.Status)
+
+		_, err = client.Verify(VerifyRequest{Number: SandboxNumberRejectedNetwork})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, ErrorCodeRejectedNetwork, apiErr.Code)
+	})
+}
+
+func TestLookupSandboxNumber(t *testing.T) {
+	for _, number := range []string{
+		"+244921000111",
+		"244921000111",
+		"00244921000111",
+		"+244 921 000 111",
+		"+244-921-000-111",
+	} {
+		scenario, ok := LookupSandboxNumber(number)
+		require.True(t, ok, number)
+		assert.Equal(t, ErrorCodeRejectedNetwork, scenario.Code, number)
+	}
+
+	for _, number := range []string{"", "+244921000999", "921000111x", "+244921000111ext"} {
+		_, ok := LookupSandboxNumber(number)
+		assert.False(t, ok, number)
+	}
+
+	seen := make(map[string]bool)
+	for _, scenario := range SandboxScenarios() {
+		assert.False(t, seen[scenario.Number], "duplicate %s", scenario.Number)
+		seen[scenario.Number] = true
+		assert.True(t, (scenario.Status == "") != (scenario.Code == ""), scenario.Number)
+	}
+}
@@ -0,0 +1,215 @@
+package checkhim
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// DeliveryStatus is the delivery state of a verification as reported by the
+// carrier network (e.g. "DELIVERED_TO_HANDSET"). Values not known to this
+// version of the SDK are preserved as received.
+type DeliveryStatus string
+
+// Códigos de status de entrega
+const (
+	// Pending states: the verification has not reached a final state yet
+	DeliveryStatusPendingAccepted        DeliveryStatus = "PENDING_ACCEPTED"
+	DeliveryStatusPendingEnroute         DeliveryStatus = "PENDING_ENROUTE"
+	DeliveryStatusPendingWaitingDelivery DeliveryStatus = "PENDING_WAITING_DELIVERY"
+
+	// Delivered states (sucesso)
+	DeliveryStatusDeliveredToHandset  DeliveryStatus = "DELIVERED_TO_HANDSET"
+	DeliveryStatusDeliveredToOperator DeliveryStatus = "DELIVERED_TO_OPERATOR"
+
+	// Undeliverable states: the network accepted the request but the handset
+	// could not be reached
+	DeliveryStatusUndeliverableNotDelivered     DeliveryStatus = "UNDELIVERABLE_NOT_DELIVERED"
+	DeliveryStatusUndeliverableRejectedOperator DeliveryStatus = "UNDELIVERABLE_REJECTED_OPERATOR"
+
+	// Expired states: no final report arrived in time
+	DeliveryStatusExpired           DeliveryStatus = "EXPIRED_EXPIRED"
+	DeliveryStatusExpiredDLRUnknown DeliveryStatus = "EXPIRED_DLR_UNKNOWN"
+
+	// Rejected states: the request was refused before delivery
+	DeliveryStatusRejectedNetwork           DeliveryStatus = "REJECTED_NETWORK"
+	DeliveryStatusRejectedPrefixMissing     DeliveryStatus = "REJECTED_PREFIX_MISSING"
+	DeliveryStatusRejectedFormat            DeliveryStatus = "REJECTED_FORMAT"
+	DeliveryStatusRejectedSubscriberAbsent  DeliveryStatus = "REJECTED_SUBSCRIBER_ABSENT"
+	DeliveryStatusRejectedUnknownSubscriber DeliveryStatus = "REJECTED_UNKNOWN_SUBSCRIBER"
+	DeliveryStatusRejectedUndeliverable     DeliveryStatus = "REJECTED_UNDELIVERABLE"
+)
+
+// DeliveryStatusGroup is the coarse state a DeliveryStatus belongs to
+type DeliveryStatusGroup string
+
+const (
+	StatusGroupPending       DeliveryStatusGroup = "PENDING"
+	StatusGroupDelivered     DeliveryStatusGroup = "DELIVERED"
+	StatusGroupUndeliverable DeliveryStatusGroup = "UNDELIVERABLE"
+	StatusGroupExpired       DeliveryStatusGroup = "EXPIRED"
+	StatusGroupRejected      DeliveryStatusGroup = "REJECTED"
+	StatusGroupUnknown       DeliveryStatusGroup = "UNKNOWN"
+)
+
+var knownDeliveryStatuses = map[DeliveryStatus]bool{
+	DeliveryStatusPendingAccepted:               true,
+	DeliveryStatusPendingEnroute:                true,
+	DeliveryStatusPendingWaitingDelivery:        true,
+	DeliveryStatusDeliveredToHandset:            true,
+	DeliveryStatusDeliveredToOperator:           true,
+	DeliveryStatusUndeliverableNotDelivered:     true,
+	DeliveryStatusUndeliverableRejectedOperator: true,
+	DeliveryStatusExpired:                       true,
+	DeliveryStatusExpiredDLRUnknown:             true,
+	DeliveryStatusRejectedNetwork:               true,
+	DeliveryStatusRejectedPrefixMissing:         true,
+	DeliveryStatusRejectedFormat:                true,
+	DeliveryStatusRejectedSubscriberAbsent:      true,
+	DeliveryStatusRejectedUnknownSubscriber:     true,
+	DeliveryStatusRejectedUndeliverable:         true,
+}
+
+// IsKnown reports whether the status is one of the DeliveryStatus constants
+func (s DeliveryStatus) IsKnown() bool {
+	return knownDeliveryStatuses[s]
+}
+
+// Group returns the group of the status. The group is derived from the status
+// prefix, so statuses added to the API later are still grouped correctly.
+func (s DeliveryStatus) Group() DeliveryStatusGroup {
+	name := strings.ToUpper(string(s))
+	for _, group := range []DeliveryStatusGroup{
+		StatusGroupPending,
+		StatusGroupDelivered,
+		StatusGroupUndeliverable,
+		StatusGroupExpired,
+		StatusGroupRejected,
+	} {
+		if name == string(group) || strings.HasPrefix(name, string(group)+"_") {
+			return group
+		}
+	}
+	return StatusGroupUnknown
+}
+
+// IsDelivered reports whether the verification reached the network or handset
+func (s DeliveryStatus) IsDelivered() bool {
+	return s.Group() == StatusGroupDelivered
+}
+
+// IsPending reports whether the verification is still in progress
+func (s DeliveryStatus) IsPending() bool {
+	return s.Group() == StatusGroupPending
+}
+
+// IsFailed reports whether the verification ended without delivery
+func (s DeliveryStatus) IsFailed() bool {
+	switch s.Group() {
+	case StatusGroupUndeliverable, StatusGroupExpired, StatusGroupRejected:
+		return true
+	}
+	return false
+}
+
+// IsFinal reports whether the status will not change anymore
+func (s DeliveryStatus) IsFinal() bool {
+	return s.IsDelivered() || s.IsFailed()
+}
+
+// String implements fmt.Stringer
+func (s DeliveryStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON accepts a status string, null, or an object with a "name"
+// field. Known statuses are normalized to their canonical spelling; unknown
+// values are kept verbatim.
+func (s *DeliveryStatus) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	var raw string
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*s = ""
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	case len(data) > 0 && data[0] == '{':
+		var obj struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		raw = obj.Name
+	default:
+		raw = string(data)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if normalized := DeliveryStatus(strings.ToUpper(raw)); normalized.IsKnown() {
+		*s = normalized
+		return nil
+	}
+	*s = DeliveryStatus(raw)
+	return nil
+}
+
+// Outcome is the business decision derived from a verification response
+type Outcome string
+
+const (
+	// OutcomeVerified means the number is valid and reachable
+	OutcomeVerified Outcome = "verified"
+
+	// OutcomePending means the final delivery state is not known yet
+	OutcomePending Outcome = "pending"
+
+	// OutcomeUnreachable means the number exists but the handset could not
+	// be reached
+	OutcomeUnreachable Outcome = "unreachable"
+
+	// OutcomeInvalid means the number is not valid or not in service
+	OutcomeInvalid Outcome = "invalid"
+
+	// OutcomeUnknown means the response does not allow a decision
+	OutcomeUnknown Outcome = "unknown"
+)
+
+// Outcome merges Valid, Status and Carrier into a single decision:
+//
+//   - a pending status yields OutcomePending
+//   - a delivered status yields OutcomeVerified
+//   - a failed status yields OutcomeInvalid when the number is not valid, has
+//     no carrier or is rejected for its format or subscriber, and
+//     OutcomeUnreachable otherwise
+//   - without a status, Valid decides between OutcomeVerified and
+//     OutcomeInvalid
+//   - an unrecognized status on a valid number yields OutcomeUnknown
+func (r *VerifyResponse) Outcome() Outcome {
+	switch {
+	case r.Status.IsPending():
+		return OutcomePending
+	case r.Status.IsDelivered():
+		return OutcomeVerified
+	case r.Status.IsFailed():
+		switch r.Status {
+		case DeliveryStatusRejectedFormat,
+			DeliveryStatusRejectedPrefixMissing,
+			DeliveryStatusRejectedUnknownSubscriber:
+			return OutcomeInvalid
+		}
+		if !r.Valid || r.Carrier == "" {
+			return OutcomeInvalid
+		}
+		return OutcomeUnreachable
+	case !r.Valid:
+		return OutcomeInvalid
+	case r.Status == "":
+		return OutcomeVerified
+	}
+	return OutcomeUnknown
+}
@@ -0,0 +1,84 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryStatus_Helpers(t *testing.T) {
+	tests := []struct {
+		status    DeliveryStatus
+		group     DeliveryStatusGroup
+		delivered bool
+		pending   bool
+		final     bool
+	}{
+		{DeliveryStatusDeliveredToHandset, StatusGroupDelivered, true, false, true},
+		{DeliveryStatusPendingEnroute, StatusGroupPending, false, true, false},
+		{DeliveryStatusUndeliverableNotDelivered, StatusGroupUndeliverable, false, false, true},
+		{DeliveryStatusExpiredDLRUnknown, StatusGroupExpired, false, false, true},
+		{DeliveryStatusRejectedNetwork, StatusGroupRejected, false, false, true},
+		{"PENDING_SOMETHING_NEW", StatusGroupPending, false, true, false},
+		{"MYSTERY", StatusGroupUnknown, false, false, false},
+		{"", StatusGroupUnknown, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.group, tt.status.Group())
+			assert.Equal(t, tt.delivered, tt.status.IsDelivered())
+			assert.Equal(t, tt.pending, tt.status.IsPending())
+			assert.Equal(t, tt.final, tt.status.IsFinal())
+		})
+	}
+}
+
+func TestDeliveryStatus_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want DeliveryStatus
+	}{
+		{"known string", `"DELIVERED_TO_HANDSET"`, DeliveryStatusDeliveredToHandset},
+		{"known string other casing", `" delivered_to_handset "`, DeliveryStatusDeliveredToHandset},
+		{"unknown string preserved", `"Queued_At_SMSC"`, "Queued_At_SMSC"},
+		{"null", `null`, ""},
+		{"object with name", `{"name":"PENDING_ENROUTE","groupName":"PENDING"}`, DeliveryStatusPendingEnroute},
+		{"number", `7`, "7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp VerifyResponse
+			err := json.Unmarshal([]byte(`{"valid":true,"status":`+tt.json+`}`), &resp)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.Status)
+		})
+	}
+}
+
+func TestVerifyResponse_Outcome(t *testing.T) {
+	tests := []struct {
+		name string
+		resp VerifyResponse
+		want Outcome
+	}{
+		{"valid without status", VerifyResponse{Valid: true, Carrier: "UNITEL"}, OutcomeVerified},
+		{"invalid without status", VerifyResponse{Valid: false}, OutcomeInvalid},
+		{"delivered", VerifyResponse{Valid: true, Carrier: "UNITEL", Status: DeliveryStatusDeliveredToHandset}, OutcomeVerified},
+		{"pending", VerifyResponse{Valid: true, Carrier: "UNITEL", Status: DeliveryStatusPendingEnroute}, OutcomePending},
+		{"absent subscriber", VerifyResponse{Valid: true, Carrier: "UNITEL", Status: DeliveryStatusRejectedSubscriberAbsent}, OutcomeUnreachable},
+		{"undeliverable without carrier", VerifyResponse{Valid: true, Status: DeliveryStatusUndeliverableNotDelivered}, OutcomeInvalid},
+		{"unknown subscriber", VerifyResponse{Valid: true, Carrier: "UNITEL", Status: DeliveryStatusRejectedUnknownSubscriber}, OutcomeInvalid},
+		{"unrecognized status", VerifyResponse{Valid: true, Carrier: "UNITEL", Status: "MYSTERY"}, OutcomeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.resp.Outcome())
+		})
+	}
+}
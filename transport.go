@@ -0,0 +1,207 @@
+package checkhim
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInsecureBaseURL is returned when the base URL would send the API key in
+// cleartext. Plain HTTP is only allowed for loopback hosts with
+// Config.AllowInsecureLocalhost.
+var ErrInsecureBaseURL = errors.New("checkhim: refusing to send credentials over plain HTTP")
+
+// ErrPinMismatch is returned when the server certificate chain does not
+// contain any of the pinned public keys
+var ErrPinMismatch = errors.New("checkhim: server public key does not match any pinned key")
+
+// validateBaseURL checks that baseURL is absolute and uses HTTPS. Plain HTTP
+// is accepted on a loopback host when allowInsecureLocalhost is set, and over
+// a Unix socket, since neither leaves the machine. The socket only counts when
+// the SDK's own transport dials it, i.e. without Config.HTTPClient.
+func validateBaseURL(config Config) error {
+	u, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return fmt.Errorf("checkhim: invalid base URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if (config.UnixSocket != "" && config.HTTPClient == nil) || (config.AllowInsecureLocalhost && isLoopbackHost(u.Hostname())) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrInsecureBaseURL, u.Redacted())
+	}
+	return fmt.Errorf("checkhim: invalid base URL %q: scheme must be https", config.BaseURL)
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parsePins decodes public key pins given as base64 SHA-256 digests of the
+// certificate's SubjectPublicKeyInfo, optionally prefixed with "sha256/"
+func parsePins(pins []string) ([][]byte, error) {
+	out := make([][]byte, 0, len(pins))
+	for _, pin := range pins {
+		digest, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(pin), "sha256/"))
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("checkhim: invalid public key pin %q: expected base64 SHA-256 digest", pin)
+		}
+		out = append(out, digest)
+	}
+	return out, nil
+}
+
+// PublicKeyPin returns the pin of a certificate's public key in the format
+// expected by Config.PinnedPublicKeys
+func PublicKeyPin(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// newTLSConfig builds the TLS configuration of the default transport
+func newTLSConfig(config Config) (*tls.Config, error) {
+	minVersion := config.MinTLSVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: minVersion,
+		RootCAs:    config.RootCAs,
+	}
+
+	if len(config.PinnedPublicKeys) > 0 {
+		pins, err := parsePins(config.PinnedPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		// VerifyConnection runs after the regular chain verification, so
+		// pinning is an additional check, not a replacement
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				digest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				for _, pin := range pins {
+					if bytes.Equal(digest[:], pin) {
+						return nil
+					}
+				}
+			}
+			return ErrPinMismatch
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// Defaults of the transport built when Config.HTTPClient is nil
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultDialTimeout         = 10 * time.Second
+	DefaultKeepAlive           = 30 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// newTransport builds the transport used when Config.HTTPClient is nil. It
+// keeps connections to the API warm and negotiates HTTP/2 when available.
+func newTransport(config Config) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		switch config.Proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("checkhim: unsupported proxy scheme %q", config.Proxy.Scheme)
+		}
+		proxy = http.ProxyURL(config.Proxy)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   DefaultDialTimeout,
+		KeepAlive: DefaultKeepAlive,
+	}
+	dialContext := config.DialContext
+	if dialContext == nil {
+		dialContext = dialer.DialContext
+	}
+	if config.UnixSocket != "" {
+		if config.DialContext != nil {
+			return nil, errors.New("checkhim: Config.DialContext cannot be combined with Config.UnixSocket")
+		}
+		socket := config.UnixSocket
+		proxy = nil
+		dialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	maxIdleConnsPerHost := config.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	idleConnTimeout := config.IdleConnTimeout
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = DefaultIdleConnTimeout
+	}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
+
+// newHTTPClient builds the HTTP client used when Config.HTTPClient is nil
+func newHTTPClient(config Config) (*http.Client, error) {
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout:       config.Timeout,
+		Transport:     transport,
+		CheckRedirect: refuseDowngrade,
+	}, nil
+}
+
+// refuseDowngrade stops redirects from HTTPS to plain HTTP
+func refuseDowngrade(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Scheme == "http" && via[0].URL.Scheme == "https" {
+		return fmt.Errorf("%w: redirect to %s", ErrInsecureBaseURL, req.URL.Redacted())
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package checkhim
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBaseURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		allowHTTP bool
+		wantErr   bool
+	}{
+		{"https", "https://api.checkhim.tech", false, false},
+		{"http refused by default", "http://api.checkhim.tech", false, true},
+		{"http localhost refused without opt-in", "http://localhost:8080", false, true},
+		{"http localhost with opt-in", "http://localhost:8080", true, false},
+		{"http loopback IPv4 with opt-in", "http://127.0.0.1:8080", true, false},
+		{"http loopback IPv6 with opt-in", "http://[::1]:8080", true, false},
+		{"http remote host even with opt-in", "http://api.checkhim.tech", true, true},
+		{"unsupported scheme", "ftp://api.checkhim.tech", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBaseURL(Config{BaseURL: tt.url, AllowInsecureLocalhost: tt.allowHTTP})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_RefusesPlainHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("credentials were sent over plain HTTP")
+	}))
+	defer server.Close()
+
+	client := New("test-api-key", Config{BaseURL: server.URL})
+	result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrInsecureBaseURL)
+}
+
+func TestClient_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(VerifyResponse{Carrier: "UNITEL", Valid: true})
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	t.Run("unknown authority is rejected", func(t *testing.T) {
+		client := New("test-api-key", Config{BaseURL: server.URL})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("custom root CAs", func(t *testing.T) {
+		client := New("test-api-key", Config{BaseURL: server.URL, RootCAs: roots})
+		result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+	})
+
+	t.Run("matching pin", func(t *testing.T) {
+		client := New("test-api-key", Config{
+			BaseURL:          server.URL,
+			RootCAs:          roots,
+			PinnedPublicKeys: []string{PublicKeyPin(server.Certificate())},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("mismatched pin", func(t *testing.T) {
+		client := New("test-api-key", Config{
+			BaseURL:          server.URL,
+			RootCAs:          roots,
+			PinnedPublicKeys: []string{"sha256/AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		assert.ErrorIs(t, err, ErrPinMismatch)
+	})
+
+	t.Run("malformed pin", func(t *testing.T) {
+		client := New("test-api-key", Config{
+			BaseURL:          server.URL,
+			PinnedPublicKeys: []string{"not-a-pin"},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid public key pin")
+	})
+
+	t.Run("minimum TLS version", func(t *testing.T) {
+		old := httptest.NewUnstartedServer(server.Config.Handler)
+		old.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+		old.StartTLS()
+		defer old.Close()
+
+		oldRoots := x509.NewCertPool()
+		oldRoots.AddCert(old.Certificate())
+
+		client := New("test-api-key", Config{BaseURL: old.URL, RootCAs: oldRoots, MinTLSVersion: tls.VersionTLS13})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		assert.Error(t, err)
+
+		client = New("test-api-key", Config{BaseURL: old.URL, RootCAs: oldRoots})
+		_, err = client.Verify(VerifyRequest{Number: "+244921204020"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestClient_RefusesHTTPSDowngradeRedirect(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect to plain HTTP was followed")
+	}))
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/api/verify", http.StatusTemporaryRedirect)
+	}))
+	defer secure.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(secure.Certificate())
+
+	client := New("test-api-key", Config{BaseURL: secure.URL, RootCAs: roots})
+	_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+	assert.ErrorIs(t, err, ErrInsecureBaseURL)
+}
+
+// verifyHandler answers every request with a valid verification
+var verifyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(VerifyResponse{Carrier: "UNITEL", Valid: true})
+})
+
+func TestClient_Proxy(t *testing.T) {
+	t.Run("HTTP proxy with credentials", func(t *testing.T) {
+		var proxyAuth, target string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxyAuth = r.Header.Get("Proxy-Authorization")
+			target = r.URL.String()
+			verifyHandler(w, r)
+		}))
+		defer proxy.Close()
+
+		proxyURL, err := url.Parse(proxy.URL)
+		require.NoError(t, err)
+		proxyURL.User = url.UserPassword("egress", "s3cret")
+
+		client := New("test-api-key", Config{
+			BaseURL:                "http://127.0.0.1:9",
+			AllowInsecureLocalhost: true,
+			Proxy:                  proxyURL,
+		})
+		_, err = client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("egress:s3cret")), proxyAuth)
+		assert.Equal(t, "http://127.0.0.1:9/api/verify", target)
+	})
+
+	t.Run("SOCKS5 proxy with credentials", func(t *testing.T) {
+		server := httptest.NewServer(verifyHandler)
+		defer server.Close()
+
+		socks := newSOCKS5Server(t, "egress", "s3cret")
+		defer socks.Close()
+
+		client := New("test-api-key", Config{
+			BaseURL:                server.URL,
+			AllowInsecureLocalhost: true,
+			Proxy:                  &url.URL{Scheme: "socks5", Host: socks.Addr().String(), User: url.UserPassword("egress", "s3cret")},
+		})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		client := New("test-api-key", Config{Proxy: &url.URL{Scheme: "ftp", Host: "proxy:21"}})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported proxy scheme")
+	})
+}
+
+func TestClient_UnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "checkhim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: verifyHandler, ReadHeaderTimeout: time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := New("test-api-key", Config{BaseURL: "http://checkhim-sidecar", UnixSocket: socket})
+	result, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+}
+
+func TestClient_UnixSocketWithHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("credentials were sent over plain HTTP")
+	}))
+	defer server.Close()
+
+	// a custom HTTPClient never dials the socket, so the request would go
+	// over TCP
+	client := New("test-api-key", Config{BaseURL: server.URL, UnixSocket: "/tmp/checkhim.sock", HTTPClient: http.DefaultClient})
+	_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+	assert.ErrorIs(t, err, ErrInsecureBaseURL)
+}
+
+func TestClient_UnixSocketWithDialContext(t *testing.T) {
+	client := New("test-api-key", Config{
+		BaseURL:    "http://checkhim-sidecar",
+		UnixSocket: "/tmp/checkhim.sock",
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			t.Error("dialer called")
+			return nil, errors.New("unused")
+		},
+	})
+	_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined")
+}
+
+func TestClient_DialContext(t *testing.T) {
+	server := httptest.NewServer(verifyHandler)
+	defer server.Close()
+
+	var dials int32
+	client := New("test-api-key", Config{
+		BaseURL:                server.URL,
+		AllowInsecureLocalhost: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+	}
+
+	// Connections are pooled, so one dial serves every request
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dials))
+}
+
+func TestNewTransport_Defaults(t *testing.T) {
+	transport, err := newTransport(Config{})
+	require.NoError(t, err)
+
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	assert.NotNil(t, transport.Proxy)
+
+	transport, err = newTransport(Config{MaxIdleConnsPerHost: 50, IdleConnTimeout: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+}
+
+// newSOCKS5Server starts a minimal SOCKS5 server (RFC 1928/1929) that
+// requires username/password authentication and supports CONNECT
+func newSOCKS5Server(t *testing.T, user, pass string) net.Listener {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(t, conn, user, pass)
+		}
+	}()
+	return listener
+}
+
+func serveSOCKS5(t *testing.T, conn net.Conn, user, pass string) {
+	defer conn.Close()
+
+	// Greeting: only username/password (0x02) is accepted
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	methods := make([]byte, header[1])
+	io.ReadFull(conn, methods)
+	conn.Write([]byte{0x05, 0x02})
+
+	// Username/password sub-negotiation
+	readField := func() string {
+		n := make([]byte, 1)
+		io.ReadFull(conn, n)
+		b := make([]byte, n[0])
+		io.ReadFull(conn, b)
+		return string(b)
+	}
+	io.ReadFull(conn, make([]byte, 1))
+	gotUser, gotPass := readField(), readField()
+	if gotUser != user || gotPass != pass {
+		t.Errorf("SOCKS5 credentials = %q/%q", gotUser, gotPass)
+		conn.Write([]byte{0x01, 0x01})
+		return
+	}
+	conn.Write([]byte{0x01, 0x00})
+
+	// CONNECT request
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 0x01:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 0x03:
+		host = readField()
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))))
+	if err != nil {
+		conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
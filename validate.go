@@ -0,0 +1,363 @@
+package checkhim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StructTag is the struct tag read by ValidateStruct. Its options are
+// "verify", to verify the number with the API, and "required", to reject an
+// empty value:
+//
+//	type Signup struct {
+//		Phone    string   `checkhim:"verify,required"`
+//		Backup   *string  `checkhim:"verify"`
+//		Contacts []Contact
+//	}
+const StructTag = "checkhim"
+
+// DefaultValidateConcurrency is the number of verifications ValidateStruct
+// runs at once
+const DefaultValidateConcurrency = 4
+
+var (
+	// ErrFieldRequired is reported for an empty field tagged "required"
+	ErrFieldRequired = errors.New("checkhim: phone number is required")
+
+	// ErrNumberRejected is reported for a number whose verification outcome
+	// is OutcomeInvalid
+	ErrNumberRejected = errors.New("checkhim: phone number rejected")
+)
+
+// FieldError describes a field that failed validation
+type FieldError struct {
+	// Path locates the field, e.g. "Contacts[1].Phone"
+	Path string
+
+	// Number is the value of the field
+	Number string
+
+	// Err is ErrFieldRequired, ErrNumberRejected or the *APIError returned
+	// for the number
+	Err error
+
+	// Response is the verification result of a rejected number
+	Response *VerifyResponse
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	if e.Response != nil && e.Response.Status != "" {
+		return fmt.Sprintf("%s: %v (%s)", e.Path, e.Err, e.Response.Status)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns Err
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError aggregates the fields that failed ValidateStruct
+type ValidationError struct {
+	// Fields maps field paths to their error
+	Fields map[string]*FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, path := range e.Paths() {
+		messages = append(messages, e.Fields[path].Error())
+	}
+	return "checkhim: validation failed: " + strings.Join(messages, "; ")
+}
+
+// Paths returns the paths of the failed fields, sorted
+func (e *ValidationError) Paths() []string {
+	paths := make([]string, 0, len(e.Fields))
+	for path := range e.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Unwrap returns the field errors, so errors.Is(err, ErrFieldRequired) and
+// errors.As(err, &apiErr) look into them
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Fields))
+	for _, path := range e.Paths() {
+		errs = append(errs, e.Fields[path])
+	}
+	return errs
+}
+
+// ValidateStruct verifies the phone numbers in the fields of v tagged with
+// StructTag, walking nested structs, pointers, slices and arrays. Each
+// distinct number is verified once, with up to DefaultValidateConcurrency
+// verifications in flight.
+//
+// Failed fields are reported in a *ValidationError keyed by field path.
+// Errors that say nothing about a number, such as network failures or a
+// cancelled ctx, are returned as is.
+func (c *Client) ValidateStruct(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array:
+	default:
+		return fmt.Errorf("checkhim: ValidateStruct needs a struct, got %T", v)
+	}
+
+	w := &structWalker{visited: make(map[uintptr]bool)}
+	w.walk(reflect.ValueOf(v), "")
+	if w.err != nil {
+		return w.err
+	}
+
+	validationErr := &ValidationError{Fields: make(map[string]*FieldError)}
+	numbers := make(map[string]bool)
+	for _, field := range w.fields {
+		switch {
+		case field.number == "" && field.required:
+			validationErr.Fields[field.path] = &FieldError{Path: field.path, Err: ErrFieldRequired}
+		case field.number != "" && field.verify:
+			numbers[field.number] = true
+		}
+	}
+
+	results, err := c.verifyNumbers(ctx, numbers)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range w.fields {
+		result, ok := results[field.number]
+		if !ok || !field.verify {
+			continue
+		}
+		switch {
+		case result.err != nil:
+			validationErr.Fields[field.path] = &FieldError{Path: field.path, Number: field.number, Err: result.err}
+		case result.resp.Outcome() == OutcomeInvalid:
+			validationErr.Fields[field.path] = &FieldError{Path: field.path, Number: field.number, Err: ErrNumberRejected, Response: result.resp}
+		}
+	}
+
+	if len(validationErr.Fields) == 0 {
+		return nil
+	}
+	return validationErr
+}
+
+type numberResult struct {
+	resp *VerifyResponse
+	err  error
+}
+
+// verifyNumbers verifies numbers concurrently. API errors about a number are
+// returned per number; auth, quota, server and transport errors abort the
+// whole batch.
+func (c *Client) verifyNumbers(ctx context.Context, numbers map[string]bool) (map[string]numberResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		results  = make(map[string]numberResult, len(numbers))
+		firstErr error
+		sem      = make(chan struct{}, DefaultValidateConcurrency)
+	)
+
+	for number := range numbers {
+		number := number
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			resp, err := c.VerifyWithContext(ctx, VerifyRequest{Number: number})
+
+			mu.Lock()
+			defer mu.Unlock()
+			var apiErr *APIError
+			switch {
+			case err == nil:
+				results[number] = numberResult{resp: resp}
+			case errors.As(err, &apiErr) && isNumberError(apiErr):
+				results[number] = numberResult{err: err}
+			default:
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil && len(results) < len(numbers) {
+		return nil, err
+	}
+	return results, nil
+}
+
+// isNumberError reports whether an API error refuses the number itself, as
+// opposed to the request or the account
+func isNumberError(apiErr *APIError) bool {
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 &&
+		(apiErr.IsNumberInvalid() || apiErr.IsNetworkRelated())
+}
+
+// taggedField is a phone number field found by structWalker
+type taggedField struct {
+	path     string
+	number   string
+	verify   bool
+	required bool
+}
+
+// structWalker collects the tagged fields of a value
+type structWalker struct {
+	fields  []taggedField
+	visited map[uintptr]bool
+	err     error
+}
+
+func (w *structWalker) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || w.visited[v.Pointer()] {
+			return
+		}
+		// only pointers on the current path are tracked, so shared values
+		// are walked once per path and cycles are cut
+		w.visited[v.Pointer()] = true
+		w.walk(v.Elem(), path)
+		delete(w.visited, v.Pointer())
+
+	case reflect.Interface:
+		if !v.IsNil() {
+			w.walk(v.Elem(), path)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if !mayContainStructs(v.Type().Elem()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i), indexPath(path, i))
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			tag, ok := field.Tag.Lookup(StructTag)
+			if tag == "-" {
+				continue
+			}
+
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinPath(path, field.Name)
+			}
+			if !ok {
+				w.walk(v.Field(i), fieldPath)
+				continue
+			}
+
+			var verify, required bool
+			for _, option := range strings.Split(tag, ",") {
+				switch strings.TrimSpace(option) {
+				case "verify":
+					verify = true
+				case "required":
+					required = true
+				case "":
+				default:
+					w.fail(fmt.Errorf("checkhim: unknown %s tag option %q on %s", StructTag, option, fieldPath))
+				}
+			}
+			w.tagged(v.Field(i), fieldPath, verify, required)
+		}
+	}
+}
+
+// tagged collects a field tagged with StructTag, or each element of a tagged
+// slice
+func (w *structWalker) tagged(v reflect.Value, path string, verify, required bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			w.fields = append(w.fields, taggedField{path: path, verify: verify, required: required})
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		w.fields = append(w.fields, taggedField{
+			path:     path,
+			number:   strings.TrimSpace(v.String()),
+			verify:   verify,
+			required: required,
+		})
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 && required {
+			w.fields = append(w.fields, taggedField{path: path, required: true})
+		}
+		for i := 0; i < v.Len(); i++ {
+			w.tagged(v.Index(i), indexPath(path, i), verify, required)
+		}
+	default:
+		w.fail(fmt.Errorf("checkhim: field %s tagged %s is a %s, not a string", path, StructTag, v.Type()))
+	}
+}
+
+func (w *structWalker) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// mayContainStructs reports whether values of t can hold tagged fields
+func mayContainStructs(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
@@ -0,0 +1,204 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testContact struct {
+	Name  string
+	Phone string `checkhim:"verify,required"`
+}
+
+type testAddress struct {
+	Line1 string
+	Phone *string `checkhim:"verify"`
+}
+
+type testSignup struct {
+	Phone    string   `checkhim:"verify,required"`
+	Backups  []string `checkhim:"verify"`
+	Contacts []testContact
+	Address  *testAddress
+	Internal string `checkhim:"-"`
+	Fax      string `checkhim:"required"`
+	Ignored  string
+}
+
+// newValidateServer answers verifications by the last digit of the number:
+// 1 is rejected, 2 fails with invalid_number, 3 with a 400
+// REJECTED_UNKNOWN_SUBSCRIBER error, 9 with a server error and anything else
+// is delivered. It records how often each number was verified.
+func newValidateServer(t *testing.T) (*httptest.Server, map[string]int) {
+	t.Helper()
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		calls[req.Number]++
+		mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(req.Number, "1"):
+			json.NewEncoder(w).Encode(VerifyResponse{Valid: false, Status: DeliveryStatusRejectedUnknownSubscriber})
+		case strings.HasSuffix(req.Number, "2"):
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid number", Code: ErrorCodeInvalidNumber})
+		case strings.HasSuffix(req.Number, "3"):
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unknown subscriber", Code: ErrorCodeRejectedUnknownSubscriber})
+		case strings.HasSuffix(req.Number, "9"):
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Internal error"})
+		default:
+			json.NewEncoder(w).Encode(VerifyResponse{Valid: true, Carrier: "UNITEL", Status: DeliveryStatusDeliveredToHandset})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, calls
+}
+
+func TestClient_ValidateStruct(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports failed fields by path", func(t *testing.T) {
+		server, calls := newValidateServer(t)
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		bad := "+244921204021"
+		signup := &testSignup{
+			Phone:   "+244921204020",
+			Backups: []string{"+244921204020", "+244921204022"},
+			Contacts: []testContact{
+				{Name: "ok", Phone: "+244921204030"},
+				{Name: "empty"},
+				{Name: "rejected", Phone: bad},
+			},
+			Address:  &testAddress{Phone: &bad},
+			Internal: "+244921204029",
+			Ignored:  "+244921204039",
+		}
+
+		err := client.ValidateStruct(ctx, signup)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{
+			"Address.Phone",
+			"Backups[1]",
+			"Contacts[1].Phone",
+			"Contacts[2].Phone",
+			"Fax",
+		}, validationErr.Paths())
+
+		assert.ErrorIs(t, validationErr.Fields["Contacts[1].Phone"], ErrFieldRequired)
+		assert.ErrorIs(t, validationErr.Fields["Fax"], ErrFieldRequired)
+
+		rejected := validationErr.Fields["Contacts[2].Phone"]
+		assert.ErrorIs(t, rejected, ErrNumberRejected)
+		assert.Equal(t, bad, rejected.Number)
+		assert.Equal(t, DeliveryStatusRejectedUnknownSubscriber, rejected.Response.Status)
+
+		var apiErr *APIError
+		require.ErrorAs(t, validationErr.Fields["Backups[1]"], &apiErr)
+		assert.Equal(t, ErrorCodeInvalidNumber, apiErr.Code)
+
+		assert.ErrorIs(t, err, ErrNumberRejected)
+		assert.Contains(t, err.Error(), "Contacts[2].Phone: checkhim: phone number rejected (REJECTED_UNKNOWN_SUBSCRIBER)")
+
+		// each distinct number is verified once; untagged fields are not
+		assert.Equal(t, map[string]int{
+			"+244921204020": 1,
+			"+244921204022": 1,
+			"+244921204030": 1,
+			bad:             1,
+		}, calls)
+	})
+
+	t.Run("valid struct", func(t *testing.T) {
+		server, _ := newValidateServer(t)
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		err := client.ValidateStruct(ctx, testSignup{Phone: "+244921204020", Fax: "+244222000000"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("slices of structs", func(t *testing.T) {
+		server, _ := newValidateServer(t)
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		err := client.ValidateStruct(ctx, []*testContact{{Phone: "+244921204020"}, {Phone: "+244921204021"}})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"[1].Phone"}, validationErr.Paths())
+	})
+
+	t.Run("embedded structs and cycles", func(t *testing.T) {
+		type node struct {
+			testContact
+			Next *node
+		}
+		server, _ := newValidateServer(t)
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		n := &node{testContact: testContact{Phone: "+244921204021"}}
+		n.Next = n
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, client.ValidateStruct(ctx, n), &validationErr)
+		assert.Equal(t, []string{"Phone"}, validationErr.Paths())
+	})
+
+	t.Run("network rejections sent as errors", func(t *testing.T) {
+		server, _ := newValidateServer(t)
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		err := client.ValidateStruct(ctx, []testContact{{Phone: "+244921204020"}, {Phone: "+244921204023"}})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"[1].Phone"}, validationErr.Paths())
+
+		var apiErr *APIError
+		require.ErrorAs(t, validationErr.Fields["[1].Phone"], &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, ErrorCodeRejectedUnknownSubscriber, apiErr.Code)
+	})
+
+	t.Run("aborts on errors unrelated to numbers", func(t *testing.T) {
+		server, _ := newValidateServer(t)
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+
+		err := client.ValidateStruct(ctx, testContact{Phone: "+244921204029"})
+
+		var validationErr *ValidationError
+		assert.False(t, errors.As(err, &validationErr))
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		client := New("test-api-key")
+
+		assert.Error(t, client.ValidateStruct(ctx, "+244921204020"))
+		assert.ErrorContains(t, client.ValidateStruct(ctx, struct {
+			Phone int `checkhim:"verify"`
+		}{}), "not a string")
+		assert.ErrorContains(t, client.ValidateStruct(ctx, struct {
+			Phone string `checkhim:"verify,strict"`
+		}{}), "unknown checkhim tag option")
+	})
+}
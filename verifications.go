@@ -0,0 +1,163 @@
+package checkhim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListVerificationsParams filters the verifications returned by
+// ListVerifications. Zero fields do not filter.
+type ListVerificationsParams struct {
+	// From and To bound the creation time of the verifications; From is
+	// inclusive and To exclusive
+	From time.Time
+	To   time.Time
+
+	// Number restricts the results to a phone number
+	Number string
+
+	// Outcome restricts the results to an outcome
+	Outcome Outcome
+
+	// PageSize is the number of verifications fetched per request (optional,
+	// defaults to the API default)
+	PageSize int
+}
+
+// verificationPage is a page of the verification list
+type verificationPage struct {
+	Data       []VerifyResponse `json:"data"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// GetVerification fetches a past verification by ID
+func (c *Client) GetVerification(ctx context.Context, id string) (*VerifyResponse, error) {
+	if id == "" {
+		return nil, ErrMissingVerificationID
+	}
+
+	var verifyResp VerifyResponse
+	if err := c.do(ctx, http.MethodGet, "/api/verifications/"+url.PathEscape(id), nil, nil, &verifyResp); err != nil {
+		return nil, err
+	}
+	return &verifyResp, nil
+}
+
+// ListVerifications returns an iterator over the verifications matching
+// params, newest first. Pages are fetched lazily as the iterator advances:
+//
+//	it := client.ListVerifications(checkhim.ListVerificationsParams{From: start})
+//	for it.Next(ctx) {
+//		v := it.Verification()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) ListVerifications(params ListVerificationsParams) *VerificationIterator {
+	return &VerificationIterator{client: c, params: params}
+}
+
+// VerificationIterator iterates over verifications, following the API's
+// pagination cursors. It is not safe for concurrent use.
+type VerificationIterator struct {
+	client *Client
+	params ListVerificationsParams
+
+	page    []VerifyResponse
+	pos     int
+	cursor  string
+	fetched bool
+	current *VerifyResponse
+	err     error
+}
+
+// errCursorLoop is returned when the API hands back the cursor it was given,
+// which would otherwise make the iterator loop forever
+var errCursorLoop = errors.New("checkhim: pagination cursor did not advance")
+
+// Next advances to the next verification, fetching the next page when
+// needed. It returns false when there are no more verifications, when ctx is
+// done or when a request fails; Err tells those cases apart.
+func (it *VerificationIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	for it.pos >= len(it.page) {
+		if it.fetched && it.cursor == "" {
+			it.current = nil
+			return false
+		}
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			it.current = nil
+			return false
+		}
+	}
+
+	it.current = &it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Verification returns the verification at the current position
+func (it *VerificationIterator) Verification() *VerifyResponse {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any. API failures are
+// returned as *APIError.
+func (it *VerificationIterator) Err() error {
+	return it.err
+}
+
+// fetch loads the page at the current cursor
+func (it *VerificationIterator) fetch(ctx context.Context) error {
+	query := url.Values{}
+	if !it.params.From.IsZero() {
+		query.Set("from", it.params.From.UTC().Format(time.RFC3339))
+	}
+	if !it.params.To.IsZero() {
+		query.Set("to", it.params.To.UTC().Format(time.RFC3339))
+	}
+	if it.params.Number != "" {
+		query.Set("number", it.params.Number)
+	}
+	if it.params.Outcome != "" {
+		query.Set("outcome", string(it.params.Outcome))
+	}
+	if it.params.PageSize > 0 {
+		query.Set("limit", strconv.Itoa(it.params.PageSize))
+	}
+	if it.cursor != "" {
+		query.Set("cursor", it.cursor)
+	}
+
+	path := "/api/verifications"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var page verificationPage
+	if err := it.client.do(ctx, http.MethodGet, path, nil, nil, &page); err != nil {
+		return err
+	}
+	if page.NextCursor != "" && page.NextCursor == it.cursor {
+		return errCursorLoop
+	}
+
+	it.page = page.Data
+	it.pos = 0
+	it.cursor = page.NextCursor
+	it.fetched = true
+	return nil
+}
@@ -0,0 +1,191 @@
+package checkhim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newListServer serves total verifications, pageSize per page, using the
+// offset as cursor. Requests for the page at failAt fail with a 500.
+func newListServer(t *testing.T, total, pageSize, failAt int, requests *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/verifications", r.URL.Path)
+		atomic.AddInt32(requests, 1)
+
+		offset := 0
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			var err error
+			offset, err = strconv.Atoi(cursor)
+			require.NoError(t, err)
+		}
+		if offset/pageSize == failAt {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Internal error"})
+			return
+		}
+
+		var page verificationPage
+		for i := offset; i < offset+pageSize && i < total; i++ {
+			page.Data = append(page.Data, VerifyResponse{ID: "ver_" + strconv.Itoa(i), Valid: true})
+		}
+		if offset+pageSize < total {
+			page.NextCursor = strconv.Itoa(offset + pageSize)
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func collectIDs(ctx context.Context, it *VerificationIterator) []string {
+	var ids []string
+	for it.Next(ctx) {
+		ids = append(ids, it.Verification().ID)
+	}
+	return ids
+}
+
+func TestClient_GetVerification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		if r.URL.EscapedPath() != "/api/verifications/ver%2F1" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Verification not found", Code: "not_found"})
+			return
+		}
+		json.NewEncoder(w).Encode(VerifyResponse{ID: "ver/1", Valid: true, Status: DeliveryStatusDeliveredToHandset})
+	}))
+	defer server.Close()
+
+	client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+	ctx := context.Background()
+
+	t.Run("found", func(t *testing.T) {
+		result, err := client.GetVerification(ctx, "ver/1")
+		require.NoError(t, err)
+		assert.Equal(t, "ver/1", result.ID)
+		assert.Equal(t, DeliveryStatusDeliveredToHandset, result.Status)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetVerification(ctx, "ver_2")
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("empty ID", func(t *testing.T) {
+		_, err := client.GetVerification(ctx, "")
+		assert.ErrorIs(t, err, ErrMissingVerificationID)
+	})
+}
+
+func TestClient_ListVerifications(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("follows cursors", func(t *testing.T) {
+		var requests int32
+		server := newListServer(t, 5, 2, -1, &requests)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		it := client.ListVerifications(ListVerificationsParams{})
+
+		assert.Equal(t, []string{"ver_0", "ver_1", "ver_2", "ver_3", "ver_4"}, collectIDs(ctx, it))
+		assert.NoError(t, it.Err())
+		assert.Nil(t, it.Verification())
+		assert.Equal(t, int32(3), requests)
+		assert.False(t, it.Next(ctx), "exhausted iterators stay exhausted")
+		assert.Equal(t, int32(3), requests)
+	})
+
+	t.Run("empty result", func(t *testing.T) {
+		var requests int32
+		server := newListServer(t, 0, 2, -1, &requests)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		it := client.ListVerifications(ListVerificationsParams{})
+
+		assert.Empty(t, collectIDs(ctx, it))
+		assert.NoError(t, it.Err())
+	})
+
+	t.Run("stops on API errors", func(t *testing.T) {
+		var requests int32
+		server := newListServer(t, 5, 2, 1, &requests)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		it := client.ListVerifications(ListVerificationsParams{})
+
+		assert.Equal(t, []string{"ver_0", "ver_1"}, collectIDs(ctx, it))
+		var apiErr *APIError
+		require.ErrorAs(t, it.Err(), &apiErr)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.True(t, apiErr.IsTemporary())
+	})
+
+	t.Run("honors context cancellation", func(t *testing.T) {
+		var requests int32
+		server := newListServer(t, 5, 2, -1, &requests)
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		it := client.ListVerifications(ListVerificationsParams{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		require.True(t, it.Next(ctx))
+		cancel()
+
+		assert.False(t, it.Next(ctx))
+		assert.ErrorIs(t, it.Err(), context.Canceled)
+		assert.Equal(t, int32(1), requests)
+	})
+
+	t.Run("sends filters", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			assert.Equal(t, "2024-03-01T00:00:00Z", query.Get("from"))
+			assert.Equal(t, "2024-04-01T00:00:00Z", query.Get("to"))
+			assert.Equal(t, "+244921204020", query.Get("number"))
+			assert.Equal(t, "invalid", query.Get("outcome"))
+			assert.Equal(t, "50", query.Get("limit"))
+			json.NewEncoder(w).Encode(verificationPage{Data: []VerifyResponse{{ID: "ver_1"}}})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		it := client.ListVerifications(ListVerificationsParams{
+			From:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2024, 4, 1, 1, 0, 0, 0, time.FixedZone("WAT", 3600)),
+			Number:   "+244921204020",
+			Outcome:  OutcomeInvalid,
+			PageSize: 50,
+		})
+
+		assert.Equal(t, []string{"ver_1"}, collectIDs(ctx, it))
+		assert.NoError(t, it.Err())
+	})
+
+	t.Run("detects cursor loops", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(verificationPage{Data: []VerifyResponse{{ID: "ver_1"}}, NextCursor: "same"})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true})
+		it := client.ListVerifications(ListVerificationsParams{})
+
+		assert.Equal(t, []string{"ver_1"}, collectIDs(ctx, it))
+		assert.ErrorIs(t, it.Err(), errCursorLoop)
+	})
+}
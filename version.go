@@ -0,0 +1,40 @@
+package checkhim
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Version is the version of this SDK. It is updated together with the
+// release heading in CHANGELOG.md.
+const Version = "1.0.0"
+
+// userAgent builds the User-Agent header sent with every request, e.g.
+// "checkhim-go-sdk/1.0.0 (go1.21.5; linux/amd64) billing-service/2.3"
+func userAgent(appName, appVersion string) string {
+	ua := fmt.Sprintf("checkhim-go-sdk/%s (%s; %s/%s)", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	appName = userAgentToken(appName)
+	if appName == "" {
+		return ua
+	}
+	ua += " " + appName
+	if appVersion = userAgentToken(appVersion); appVersion != "" {
+		ua += "/" + appVersion
+	}
+	return ua
+}
+
+// userAgentToken makes s safe to use as a User-Agent product token
+func userAgentToken(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '/' || r == '(' || r == ')' || r < 0x21 || r > 0x7e:
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
@@ -0,0 +1,61 @@
+package checkhim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion_MatchesChangelog(t *testing.T) {
+	changelog, err := os.ReadFile("CHANGELOG.md")
+	require.NoError(t, err)
+
+	latest := regexp.MustCompile(`(?m)^## \[(\d+\.\d+\.\d+)\]`).FindSubmatch(changelog)
+	require.NotNil(t, latest, "CHANGELOG.md has no release heading")
+	assert.Equal(t, string(latest[1]), Version, "Version must match the latest release in CHANGELOG.md")
+}
+
+func TestUserAgent(t *testing.T) {
+	base := "checkhim-go-sdk/" + Version + " (" + runtime.Version() + "; " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+
+	tests := []struct {
+		name       string
+		appName    string
+		appVersion string
+		want       string
+	}{
+		{"default", "", "", base},
+		{"app name only", "billing-service", "", base + " billing-service"},
+		{"app name and version", "billing-service", "2.3.1", base + " billing-service/2.3.1"},
+		{"unsafe characters are dropped", "My App (beta)", "2/3", base + " My-App-beta/23"},
+		{"version without name is ignored", "", "2.3.1", base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, userAgent(tt.appName, tt.appVersion))
+		})
+	}
+
+	t.Run("sent with requests", func(t *testing.T) {
+		var ua string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ua = r.Header.Get("User-Agent")
+			json.NewEncoder(w).Encode(VerifyResponse{Valid: true})
+		}))
+		defer server.Close()
+
+		client := New("test-api-key", Config{BaseURL: server.URL, AllowInsecureLocalhost: true, AppName: "billing-service", AppVersion: "2.3.1"})
+		_, err := client.Verify(VerifyRequest{Number: "+244921204020"})
+		require.NoError(t, err)
+
+		assert.Equal(t, base+" billing-service/2.3.1", ua)
+	})
+}
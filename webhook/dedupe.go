@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Deduplicator records processed event IDs so redelivered or replayed events
+// are handled once. Implementations must be safe for concurrent use.
+type Deduplicator interface {
+	// Claim marks id as being processed. It reports false if id was already
+	// claimed and not released.
+	Claim(ctx context.Context, id string) (bool, error)
+
+	// Release forgets id so a later delivery is processed again
+	Release(ctx context.Context, id string) error
+}
+
+// MemoryDeduplicator is an in-process Deduplicator that remembers event IDs
+// for a fixed time
+type MemoryDeduplicator struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	sweep time.Time
+}
+
+// NewMemoryDeduplicator returns a MemoryDeduplicator remembering event IDs
+// for ttl. The ttl should be at least twice the signature tolerance so that
+// replays within the tolerance are always detected.
+func NewMemoryDeduplicator(ttl time.Duration) *MemoryDeduplicator {
+	return &MemoryDeduplicator{ttl: ttl, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// Claim implements Deduplicator
+func (d *MemoryDeduplicator) Claim(_ context.Context, id string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.After(d.sweep) {
+		for key, expiry := range d.seen {
+			if now.After(expiry) {
+				delete(d.seen, key)
+			}
+		}
+		d.sweep = now.Add(d.ttl)
+	}
+
+	if expiry, ok := d.seen[id]; ok && !now.After(expiry) {
+		return false, nil
+	}
+	d.seen[id] = now.Add(d.ttl)
+	return true, nil
+}
+
+// Release implements Deduplicator
+func (d *MemoryDeduplicator) Release(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, id)
+	return nil
+}
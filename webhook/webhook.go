@@ -0,0 +1,283 @@
+// Package webhook receives verification status callbacks from CheckHim.
+//
+// CheckHim signs every callback with a shared secret. The handler returned by
+// NewHandler verifies the signature and timestamp, drops duplicate deliveries
+// and passes each event to a typed handler function:
+//
+//	handler := webhook.NewHandler(os.Getenv("CHECKHIM_WEBHOOK_SECRET"),
+//		func(ctx context.Context, event *webhook.Event) error {
+//			log.Printf("%s: %s", event.Verification.ID, event.Verification.Status)
+//			return nil
+//		})
+//	http.Handle("/webhooks/checkhim", handler)
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	checkhim "github.com/checkhim/go-sdk"
+)
+
+const (
+	// SignatureHeader is the header carrying the callback signature, in the
+	// form "t=<unix timestamp>,v1=<hex HMAC-SHA256>"
+	SignatureHeader = "X-CheckHim-Signature"
+
+	// DefaultTolerance is the maximum accepted age of a callback
+	DefaultTolerance = 5 * time.Minute
+
+	// DefaultMaxBodyBytes is the maximum accepted callback body size
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// Event types sent by CheckHim
+const (
+	EventVerificationStatusUpdated = "verification.status_updated"
+	EventVerificationCompleted     = "verification.completed"
+)
+
+var (
+	// ErrMissingSignature is returned when the signature header is absent
+	// or malformed
+	ErrMissingSignature = errors.New("webhook: missing or malformed signature")
+
+	// ErrInvalidSignature is returned when no signature matches the body
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+	// ErrEmptySecret is returned by VerifySignature when the secret is
+	// empty, e.g. because its environment variable is not set
+	ErrEmptySecret = errors.New("webhook: empty secret")
+
+	// ErrTimestampOutsideTolerance is returned for callbacks that are too
+	// old or too far in the future, which protects against replays
+	ErrTimestampOutsideTolerance = errors.New("webhook: timestamp outside tolerance")
+)
+
+// Event is a verification status callback
+type Event struct {
+	// ID uniquely identifies the event; redeliveries share the same ID
+	ID string `json:"id"`
+
+	// Type is the event type, e.g. EventVerificationStatusUpdated
+	Type string `json:"type"`
+
+	// CreatedAt is when CheckHim created the event
+	CreatedAt time.Time `json:"created_at"`
+
+	// Verification is the verification the event refers to
+	Verification checkhim.VerifyResponse `json:"verification"`
+}
+
+// HandlerFunc processes a verified, deduplicated event. Returning an error
+// answers the callback with 500 so CheckHim delivers it again.
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// Options configures the handler returned by NewHandler
+type Options struct {
+	// Tolerance is the maximum difference between the signature timestamp
+	// and the current time (optional, defaults to DefaultTolerance)
+	Tolerance time.Duration
+
+	// Deduplicator drops events that were already processed (optional,
+	// defaults to an in-memory deduplicator remembering events for twice the
+	// tolerance). Use a shared implementation when running several replicas.
+	Deduplicator Deduplicator
+
+	// MaxBodyBytes limits the callback body size (optional, defaults to
+	// DefaultMaxBodyBytes)
+	MaxBodyBytes int64
+
+	// Now returns the current time (optional, for tests)
+	Now func() time.Time
+
+	// OnError is called with callbacks rejected before reaching the handler
+	// function, e.g. for bad signatures (optional)
+	OnError func(r *http.Request, err error)
+}
+
+// Handler is an http.Handler for CheckHim callbacks
+type Handler struct {
+	secret string
+	fn     HandlerFunc
+	opts   Options
+}
+
+// NewHandler returns an http.Handler that verifies callbacks signed with
+// secret and passes them to fn. It panics if secret is empty, since any
+// callback signed with an empty key would then be accepted.
+func NewHandler(secret string, fn HandlerFunc, opts ...Options) *Handler {
+	if secret == "" {
+		panic("webhook: NewHandler called with an empty secret")
+	}
+
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Tolerance <= 0 {
+		o.Tolerance = DefaultTolerance
+	}
+	if o.MaxBodyBytes <= 0 {
+		o.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.Deduplicator == nil {
+		o.Deduplicator = NewMemoryDeduplicator(2 * o.Tolerance)
+	}
+
+	return &Handler{secret: secret, fn: fn, opts: o}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.opts.MaxBodyBytes+1))
+	if err != nil {
+		h.reject(w, r, http.StatusBadRequest, fmt.Errorf("webhook: failed to read body: %w", err))
+		return
+	}
+	if int64(len(body)) > h.opts.MaxBodyBytes {
+		h.reject(w, r, http.StatusRequestEntityTooLarge, errors.New("webhook: body too large"))
+		return
+	}
+
+	if err := VerifySignature(h.secret, r.Header.Get(SignatureHeader), body, h.opts.Now(), h.opts.Tolerance); err != nil {
+		h.reject(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.reject(w, r, http.StatusBadRequest, fmt.Errorf("webhook: invalid payload: %w", err))
+		return
+	}
+	if event.ID == "" {
+		h.reject(w, r, http.StatusBadRequest, errors.New("webhook: event ID missing"))
+		return
+	}
+
+	ctx := r.Context()
+	first, err := h.opts.Deduplicator.Claim(ctx, event.ID)
+	if err != nil {
+		h.reject(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if !first {
+		// Already processed: acknowledge so CheckHim stops redelivering
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.fn(ctx, &event); err != nil {
+		// Let the redelivery be processed
+		_ = h.opts.Deduplicator.Release(ctx, event.ID)
+		http.Error(w, "event not processed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if h.opts.OnError != nil {
+		h.opts.OnError(r, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// Sign returns the signature header value for body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(computeMAC(secret, ts, body))
+}
+
+// VerifySignature checks a signature header against body. The header may
+// carry several v1 signatures, e.g. while the secret is being rotated; one
+// valid signature is enough. An empty secret fails with ErrEmptySecret.
+func VerifySignature(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	if secret == "" {
+		return ErrEmptySecret
+	}
+
+	var ts string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	expected := computeMAC(secret, ts, body)
+	valid := false
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrTimestampOutsideTolerance
+	}
+	return nil
+}
+
+func computeMAC(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// NewSignedRequest builds a callback request for event, signed with secret as
+// CheckHim would sign it. It is meant for testing handlers locally.
+func NewSignedRequest(target, secret string, event *Event, timestamp time.Time) (*http.Request, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+	return req, nil
+}
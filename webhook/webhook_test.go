@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	checkhim "github.com/checkhim/go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test"
+
+func newEvent(id string) *Event {
+	return &Event{
+		ID:        id,
+		Type:      EventVerificationStatusUpdated,
+		CreatedAt: time.Unix(1700000000, 0).UTC(),
+		Verification: checkhim.VerifyResponse{
+			ID:      "ver_123",
+			Valid:   true,
+			Carrier: "UNITEL",
+			Status:  checkhim.DeliveryStatusDeliveredToHandset,
+		},
+	}
+}
+
+// recorder collects the events passed to the handler function
+type recorder struct {
+	mu     sync.Mutex
+	events []*Event
+	err    error
+}
+
+func (r *recorder) handle(_ context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func serve(t *testing.T, h http.Handler, req *http.Request) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("dispatches signed events", func(t *testing.T) {
+		rec := &recorder{}
+		h := NewHandler(testSecret, rec.handle)
+
+		req, err := NewSignedRequest("/webhooks", testSecret, newEvent("evt_1"), time.Now())
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, serve(t, h, req))
+		require.Len(t, rec.events, 1)
+
+		event := rec.events[0]
+		assert.Equal(t, "evt_1", event.ID)
+		assert.Equal(t, EventVerificationStatusUpdated, event.Type)
+		assert.Equal(t, checkhim.DeliveryStatusDeliveredToHandset, event.Verification.Status)
+		assert.Equal(t, checkhim.OutcomeVerified, event.Verification.Outcome())
+		assert.NotEmpty(t, event.Verification.Raw)
+	})
+
+	t.Run("drops duplicate deliveries", func(t *testing.T) {
+		rec := &recorder{}
+		h := NewHandler(testSecret, rec.handle)
+
+		for i := 0; i < 3; i++ {
+			req, err := NewSignedRequest("/webhooks", testSecret, newEvent("evt_1"), time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, serve(t, h, req))
+		}
+		assert.Len(t, rec.events, 1)
+	})
+
+	t.Run("redelivers events the handler failed", func(t *testing.T) {
+		rec := &recorder{err: errors.New("database down")}
+		h := NewHandler(testSecret, rec.handle)
+
+		req, err := NewSignedRequest("/webhooks", testSecret, newEvent("evt_1"), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, serve(t, h, req))
+
+		rec.err = nil
+		req, err = NewSignedRequest("/webhooks", testSecret, newEvent("evt_1"), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, serve(t, h, req))
+		assert.Len(t, rec.events, 2)
+	})
+
+	t.Run("rejects bad requests", func(t *testing.T) {
+		now := time.Now()
+		signed := func(secret string, at time.Time) *http.Request {
+			req, err := NewSignedRequest("/webhooks", secret, newEvent("evt_1"), at)
+			require.NoError(t, err)
+			return req
+		}
+
+		tests := []struct {
+			name    string
+			req     *http.Request
+			status  int
+			wantErr error
+		}{
+			{"wrong secret", signed("other", now), http.StatusUnauthorized, ErrInvalidSignature},
+			{"expired timestamp", signed(testSecret, now.Add(-10*time.Minute)), http.StatusUnauthorized, ErrTimestampOutsideTolerance},
+			{"future timestamp", signed(testSecret, now.Add(10*time.Minute)), http.StatusUnauthorized, ErrTimestampOutsideTolerance},
+			{"missing signature", httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader([]byte(`{}`))), http.StatusUnauthorized, ErrMissingSignature},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := &recorder{}
+				var rejected error
+				h := NewHandler(testSecret, rec.handle, Options{
+					OnError: func(_ *http.Request, err error) { rejected = err },
+				})
+
+				assert.Equal(t, tt.status, serve(t, h, tt.req))
+				assert.ErrorIs(t, rejected, tt.wantErr)
+				assert.Empty(t, rec.events)
+			})
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		rec := &recorder{}
+		h := NewHandler(testSecret, rec.handle)
+
+		body := []byte(`{"id":"evt_1","verification":{"valid":false}}`)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader([]byte(`{"id":"evt_1","verification":{"valid":true}}`)))
+		req.Header.Set(SignatureHeader, Sign(testSecret, time.Now(), body))
+
+		assert.Equal(t, http.StatusUnauthorized, serve(t, h, req))
+		assert.Empty(t, rec.events)
+	})
+
+	t.Run("invalid payloads", func(t *testing.T) {
+		for _, body := range []string{`not json`, `{"type":"verification.completed"}`} {
+			rec := &recorder{}
+			h := NewHandler(testSecret, rec.handle)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader([]byte(body)))
+			req.Header.Set(SignatureHeader, Sign(testSecret, time.Now(), []byte(body)))
+
+			assert.Equal(t, http.StatusBadRequest, serve(t, h, req), body)
+		}
+	})
+
+	t.Run("oversized body", func(t *testing.T) {
+		h := NewHandler(testSecret, (&recorder{}).handle, Options{MaxBodyBytes: 16})
+
+		req, err := NewSignedRequest("/webhooks", testSecret, newEvent("evt_1"), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, serve(t, h, req))
+	})
+
+	t.Run("only POST", func(t *testing.T) {
+		h := NewHandler(testSecret, (&recorder{}).handle)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+	})
+
+	t.Run("over HTTP", func(t *testing.T) {
+		rec := &recorder{}
+		server := httptest.NewServer(NewHandler(testSecret, rec.handle))
+		defer server.Close()
+
+		req, err := NewSignedRequest(server.URL, testSecret, newEvent("evt_1"), time.Now())
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, rec.events, 1)
+	})
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+
+	t.Run("accepts any of several signatures", func(t *testing.T) {
+		header := Sign("old-secret", now, body) + "," + Sign(testSecret, now, body)[len("t=1700000000,"):]
+		assert.NoError(t, VerifySignature(testSecret, header, body, now, DefaultTolerance))
+		assert.NoError(t, VerifySignature("old-secret", header, body, now, DefaultTolerance))
+	})
+
+	t.Run("malformed headers", func(t *testing.T) {
+		for _, header := range []string{"", "t=abc,v1=00", "t=1700000000", "v1=zz"} {
+			assert.ErrorIs(t, VerifySignature(testSecret, header, body, now, DefaultTolerance), ErrMissingSignature, header)
+		}
+	})
+
+	t.Run("empty secret", func(t *testing.T) {
+		assert.ErrorIs(t, VerifySignature("", Sign("", now, body), body, now, DefaultTolerance), ErrEmptySecret)
+		assert.Panics(t, func() {
+			NewHandler("", func(context.Context, *Event) error { return nil })
+		})
+	})
+}
+
+func TestMemoryDeduplicator(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	d := NewMemoryDeduplicator(time.Minute)
+	d.now = func() time.Time { return now }
+
+	first, err := d.Claim(ctx, "evt_1")
+	require.NoError(t, err)
+	assert.True(t, first)
+
+	first, _ = d.Claim(ctx, "evt_1")
+	assert.False(t, first)
+
+	require.NoError(t, d.Release(ctx, "evt_1"))
+	first, _ = d.Claim(ctx, "evt_1")
+	assert.True(t, first)
+
+	now = now.Add(2 * time.Minute)
+	first, _ = d.Claim(ctx, "evt_1")
+	assert.True(t, first, "expired IDs are forgotten")
+}